@@ -0,0 +1,11 @@
+//go:build go1.21
+
+package pidfd
+
+import "context"
+
+// afterFunc arranges to call f in its own goroutine after ctx is done, as
+// with context.AfterFunc. No goroutine is started unless ctx is done.
+func afterFunc(ctx context.Context, f func()) (stop func() bool) {
+	return context.AfterFunc(ctx, f)
+}
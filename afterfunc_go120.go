@@ -0,0 +1,42 @@
+//go:build !go1.21
+
+package pidfd
+
+import (
+	"context"
+	"sync"
+)
+
+// afterFunc arranges to call f in its own goroutine after ctx is done, as
+// with context.AfterFunc, which requires Go 1.21. Calling stop prevents f from
+// being called and reports whether it did so.
+func afterFunc(ctx context.Context, f func()) (stop func() bool) {
+	var (
+		once    sync.Once
+		stopped bool
+		stopC   = make(chan struct{})
+	)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stopC:
+			return
+		}
+
+		var run bool
+		once.Do(func() { run = true })
+		if run {
+			f()
+		}
+	}()
+
+	return func() bool {
+		once.Do(func() {
+			stopped = true
+			close(stopC)
+		})
+
+		return stopped
+	}
+}
@@ -0,0 +1,27 @@
+package pidfd
+
+// A CapSet contains the capability sets of a process, as described in
+// capabilities(7). Each set is a bitmask indexed by capability number, such
+// as unix.CAP_KILL.
+type CapSet struct {
+	Effective, Permitted, Inheritable uint64
+}
+
+// Has reports whether capability cap is in the effective set, which is the
+// set the kernel uses for permission checks.
+func (cs CapSet) Has(cap int) bool {
+	if cap < 0 || cap >= 64 {
+		return false
+	}
+
+	return cs.Effective&(1<<uint(cap)) != 0
+}
+
+// Capabilities returns the capability sets of the process referred to by
+// File. If the process no longer exists, an *Error value is returned which is
+// compatible with errors.Is(err, os.ErrNotExist).
+//
+// The capabilities are read from /proc/<pid>/status and may change at any
+// time, so they should not be relied upon for authorization of operations
+// which the process can perform on its own behalf.
+func (f *File) Capabilities() (CapSet, error) { return f.capabilities() }
@@ -0,0 +1,38 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"errors"
+	"strconv"
+)
+
+// capabilities implements File.Capabilities.
+func (f *File) capabilities() (CapSet, error) {
+	kvs, err := f.procKeyValues("status")
+	if err != nil {
+		return CapSet{}, err
+	}
+
+	var cs CapSet
+	for _, v := range []struct {
+		key string
+		dst *uint64
+	}{
+		{key: "CapEff", dst: &cs.Effective},
+		{key: "CapPrm", dst: &cs.Permitted},
+		{key: "CapInh", dst: &cs.Inheritable},
+	} {
+		// Capability sets are reported as hexadecimal bitmasks.
+		s, ok := kvs[v.key]
+		if !ok {
+			return CapSet{}, f.wrap(errors.New("missing status field " + strconv.Quote(v.key)))
+		}
+
+		if *v.dst, err = strconv.ParseUint(s, 16, 64); err != nil {
+			return CapSet{}, f.wrap(err)
+		}
+	}
+
+	return cs, nil
+}
@@ -0,0 +1,107 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestFileCapabilities(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	caps, err := f.Capabilities()
+	if err != nil {
+		t.Fatalf("failed to get capabilities: %v", err)
+	}
+
+	// The child runs with the same credentials as the test, so it has the
+	// same capabilities.
+	if diff := cmp.Diff(testSelfCapabilities(t), caps); diff != "" {
+		t.Fatalf("unexpected CapSet (-want +got):\n%s", diff)
+	}
+
+	if want, got := caps.Effective&(1<<unix.CAP_KILL) != 0, caps.Has(unix.CAP_KILL); want != got {
+		t.Fatalf("unexpected CAP_KILL: want %v, got %v", want, got)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.Capabilities(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestCapSetHas(t *testing.T) {
+	t.Parallel()
+
+	cs := pidfd.CapSet{
+		Effective: 1<<unix.CAP_KILL | 1<<63,
+		Permitted: 1 << unix.CAP_SYS_ADMIN,
+	}
+
+	tests := []struct {
+		name string
+		cap  int
+		ok   bool
+	}{
+		{name: "effective", cap: unix.CAP_KILL, ok: true},
+		{name: "highest", cap: 63, ok: true},
+		{name: "permitted only", cap: unix.CAP_SYS_ADMIN},
+		{name: "negative", cap: -1},
+		{name: "out of range", cap: 64},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.ok, cs.Has(tt.cap)); diff != "" {
+				t.Fatalf("unexpected Has (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func testSelfCapabilities(t *testing.T) pidfd.CapSet {
+	t.Helper()
+
+	b, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		t.Fatalf("failed to read status: %v", err)
+	}
+
+	var cs pidfd.CapSet
+	for _, line := range strings.Split(string(b), "\n") {
+		k, v, _ := strings.Cut(line, ":")
+
+		var dst *uint64
+		switch k {
+		case "CapEff":
+			dst = &cs.Effective
+		case "CapPrm":
+			dst = &cs.Permitted
+		case "CapInh":
+			dst = &cs.Inheritable
+		default:
+			continue
+		}
+
+		if *dst, err = strconv.ParseUint(strings.TrimSpace(v), 16, 64); err != nil {
+			t.Fatalf("failed to parse %s: %v", k, err)
+		}
+	}
+
+	return cs
+}
@@ -0,0 +1,5 @@
+//go:build !linux
+
+package pidfd
+
+func (*File) capabilities() (CapSet, error) { return CapSet{}, errUnimplemented }
@@ -0,0 +1,63 @@
+package pidfd
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSameCgroup is returned via errors.Is when Freeze is called for a process
+// whose cgroup contains the caller, which would freeze the caller as well.
+var ErrSameCgroup = errors.New("pidfd: process shares the caller's cgroup")
+
+// Freeze freezes the cgroup v2 control group of the process referred to by
+// File by writing to its cgroup.freeze file, and then waits until the freeze
+// is complete, as reported by the cgroup's cgroup.events file. Note that every
+// process in the control group and its descendants is frozen, not just the
+// process referred to by File.
+//
+// Processes started by exec.Cmd or Start share the caller's cgroup unless
+// they are moved elsewhere, such as with MoveToCgroup. If the caller is a
+// member of the control group or one of its descendants, Freeze returns an
+// *Error compatible with errors.Is(err, ErrSameCgroup) rather than freezing
+// the caller.
+//
+// Freeze requires cgroup v2 and write access to the control group, which
+// typically means the caller must own the cgroup through delegation or be
+// privileged. Paths are resolved using the caller's cgroup v2 mount, so a
+// control group which is not beneath that mount, such as one outside of the
+// caller's cgroup namespace, cannot be frozen.
+//
+// If the context is canceled before the freeze is complete, Freeze will
+// unblock and return an error. The freeze remains requested until Thaw is
+// called.
+func (f *File) Freeze(ctx context.Context) error { return f.traceError(ctx, f.freeze(ctx)) }
+
+// Thaw reverses the effects of Freeze. The same requirements apply, except
+// that Thaw does not wait and may be used on any control group.
+func (f *File) Thaw() error { return f.thaw() }
+
+// MoveToCgroup moves the process referred to by File into the cgroup v2
+// control group whose directory is open as cgroupFD, by writing the process's
+// pid to the cgroup's cgroup.procs file. All threads of the process are
+// moved. The caller retains ownership of cgroupFD.
+//
+// The pidfd is used to check that the process still exists beforehand, which
+// narrows but cannot eliminate the window for moving a process which reused
+// the pid. MoveToCgroup requires write access to cgroup.procs in both the
+// destination cgroup and the common ancestor of the source and destination
+// cgroups, which typically means the caller must own the cgroups through
+// delegation or be privileged.
+func (f *File) MoveToCgroup(cgroupFD int) error { return f.moveToCgroup(cgroupFD) }
+
+// CgroupID returns the ID of the cgroup v2 control group of the process
+// referred to by File, as used by eBPF helpers such as
+// bpf_get_current_cgroup_id, so that per-cgroup metrics can be joined with
+// the process.
+//
+// On Linux 6.13+, the ID is retrieved directly from the pidfd using the
+// PIDFD_GET_INFO ioctl. On older kernels, CgroupID falls back to resolving the
+// process's cgroup directory from /proc/<pid>/cgroup and decoding the ID from
+// the directory's file handle with name_to_handle_at(2), which requires Linux
+// 4.18+ and cgroup v2. The fallback reads the cgroup by path, so the pidfd is
+// used to check that the process still exists afterward.
+func (f *File) CgroupID() (uint64, error) { return f.cgroupID() }
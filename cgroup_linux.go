@@ -0,0 +1,253 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// errNoCgroup2 indicates that a process is not a member of a cgroup v2
+// hierarchy.
+var errNoCgroup2 = errors.New("pidfd: process is not a member of a cgroup v2 hierarchy")
+
+// freezeInterval is how often Freeze checks whether a cgroup is frozen.
+const freezeInterval = 10 * time.Millisecond
+
+// freeze implements File.Freeze.
+func (f *File) freeze(ctx context.Context) error {
+	path, err := f.cgroupPath()
+	if err != nil {
+		return err
+	}
+
+	// Freezing a cgroup also freezes its descendants, so the caller must not
+	// be a member of either.
+	b, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return f.wrap(err)
+	}
+	self, ok := cgroup2Path(b)
+	if !ok {
+		return f.wrap(errNoCgroup2)
+	}
+	if path == "/" || self == path || strings.HasPrefix(self, path+"/") {
+		return f.wrap(ErrSameCgroup)
+	}
+
+	dir, err := f.cgroupDirOf(path)
+	if err != nil {
+		return err
+	}
+
+	if err := writeFile(filepath.Join(dir, "cgroup.freeze"), []byte("1")); err != nil {
+		return f.wrap(err)
+	}
+
+	// Writing cgroup.freeze only requests the freeze, which is complete once
+	// cgroup.events reports it.
+	tick := time.NewTicker(freezeInterval)
+	defer tick.Stop()
+
+	for {
+		b, err := os.ReadFile(filepath.Join(dir, "cgroup.events"))
+		if err != nil {
+			return f.wrap(err)
+		}
+
+		for _, line := range strings.Split(string(b), "\n") {
+			if line == "frozen 1" {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+}
+
+// thaw implements File.Thaw.
+func (f *File) thaw() error {
+	dir, err := f.cgroupDir()
+	if err != nil {
+		return err
+	}
+
+	return f.wrap(writeFile(filepath.Join(dir, "cgroup.freeze"), []byte("0")))
+}
+
+// moveToCgroup implements File.MoveToCgroup.
+func (f *File) moveToCgroup(cgroupFD int) error {
+	if err := f.exists(); err != nil {
+		return err
+	}
+
+	fd, err := unix.Openat(cgroupFD, "cgroup.procs", unix.O_WRONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return f.wrap(os.NewSyscallError("openat", err))
+	}
+
+	procs := os.NewFile(uintptr(fd), "cgroup.procs")
+	defer procs.Close()
+
+	if _, err := procs.WriteString(strconv.Itoa(f.pid)); err != nil {
+		return f.wrap(err)
+	}
+
+	return f.wrap(procs.Close())
+}
+
+// cgroupID implements File.CgroupID.
+func (f *File) cgroupID() (uint64, error) {
+	pi, err := f.pidfdInfo(pidfdInfoCgroupID)
+	switch {
+	case err == nil && pi.Mask&pidfdInfoCgroupID != 0:
+		return pi.CgroupID, nil
+	case err == nil, errors.Is(err, unix.ENOTTY), errors.Is(err, unix.EINVAL):
+		// PIDFD_GET_INFO or its cgroup ID is not supported, use the cgroup's
+		// file handle instead.
+	default:
+		return 0, err
+	}
+
+	dir, err := f.cgroupDir()
+	if err != nil {
+		return 0, err
+	}
+
+	h, _, err := unix.NameToHandleAt(unix.AT_FDCWD, dir, 0)
+	if err != nil {
+		return 0, f.wrap(os.NewSyscallError("name_to_handle_at", err))
+	}
+
+	// The file handle of a kernfs directory is its 64-bit ID in native byte
+	// order, which matches the cgroup ID.
+	b := h.Bytes()
+	if len(b) < 8 {
+		return 0, f.wrap(fmt.Errorf("unexpected cgroup file handle length: %d", len(b)))
+	}
+	var id uint64
+	copy((*[8]byte)(unsafe.Pointer(&id))[:], b)
+
+	if err := f.exists(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// cgroupDir returns the absolute path to the cgroup v2 directory of the
+// process referred to by File.
+func (f *File) cgroupDir() (string, error) {
+	path, err := f.cgroupPath()
+	if err != nil {
+		return "", err
+	}
+
+	return f.cgroupDirOf(path)
+}
+
+// cgroupPath returns the cgroup v2 path of the process referred to by File,
+// relative to the root of the caller's cgroup namespace.
+func (f *File) cgroupPath() (string, error) {
+	b, err := f.readProc("cgroup")
+	if err != nil {
+		return "", err
+	}
+
+	path, ok := cgroup2Path(b)
+	if !ok {
+		return "", f.wrap(errNoCgroup2)
+	}
+
+	return path, nil
+}
+
+// cgroupDirOf returns the absolute path to the directory of the cgroup v2
+// path, which is relative to the root of the caller's cgroup namespace.
+func (f *File) cgroupDirOf(path string) (string, error) {
+	root, mount, err := cgroup2Mount()
+	if err != nil {
+		return "", f.wrap(err)
+	}
+
+	// The mount may expose only a subtree of the hierarchy, such as when the
+	// cgroup2 filesystem was mounted outside of the caller's cgroup
+	// namespace, in which case paths outside of that subtree are unreachable.
+	rel := path
+	if root != "/" {
+		var ok bool
+		rel, ok = strings.CutPrefix(path, root)
+		if !ok || (rel != "" && rel[0] != '/') {
+			return "", f.wrap(fmt.Errorf("cgroup %q is not visible beneath cgroup2 mount root %q", path, root))
+		}
+	}
+
+	return filepath.Join(mount, rel), nil
+}
+
+// cgroup2Path parses the cgroup v2 path from the contents of a
+// /proc/<pid>/cgroup file, reporting whether it was found.
+func cgroup2Path(b []byte) (string, bool) {
+	// From cgroups(7): "For the cgroups version 2 hierarchy, [the
+	// hierarchy ID] field contains the value 0" and the controller list is
+	// empty.
+	for _, line := range strings.Split(string(b), "\n") {
+		if p, ok := strings.CutPrefix(line, "0::"); ok && p != "" {
+			return p, true
+		}
+	}
+
+	return "", false
+}
+
+// cgroup2Mount returns the root within the cgroup v2 hierarchy and the mount
+// point of a cgroup v2 mount. The mount point may not be /sys/fs/cgroup on
+// systems which also use cgroup v1.
+func cgroup2Mount() (root, mount string, err error) {
+	b, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return "", "", err
+	}
+
+	// From proc(5), the fields of each line are:
+	//
+	// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+	//
+	// where the root of the mount within the filesystem is the fourth field,
+	// the mount point is the fifth field, and the filesystem type follows the
+	// separator.
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		pre, post, ok := strings.Cut(s.Text(), " - ")
+		if !ok || !strings.HasPrefix(post, "cgroup2 ") {
+			continue
+		}
+
+		fields := strings.Fields(pre)
+		if len(fields) < 5 {
+			continue
+		}
+
+		return fields[3], fields[4], nil
+	}
+	if err := s.Err(); err != nil {
+		return "", "", err
+	}
+
+	return "", "", errNoCgroup2
+}
@@ -0,0 +1,146 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestFileFreezeNotExist(t *testing.T) {
+	t.Parallel()
+
+	// Freezing a live process would freeze every process in its cgroup,
+	// including the test binary, so only exercise the error path.
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+	testReap(t, cmd)
+
+	if err := f.Freeze(ctx); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for freeze, but got: %v", err)
+	}
+	if err := f.Thaw(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for thaw, but got: %v", err)
+	}
+}
+
+func TestFileFreezeSameCgroup(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+	_ = testCgroup2Dir(t)
+
+	// The child shares the test process's cgroup, so freezing it would freeze
+	// the test binary too.
+	if err := f.Freeze(ctx); !errors.Is(err, pidfd.ErrSameCgroup) {
+		t.Fatalf("expected same cgroup, but got: %v", err)
+	}
+}
+
+func TestFileMoveToCgroup(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	// Moving the child into the cgroup it already shares with the test
+	// process is always permitted and has no side effects.
+	dir, err := os.Open(testCgroup2Dir(t))
+	if err != nil {
+		t.Fatalf("failed to open cgroup: %v", err)
+	}
+	defer dir.Close()
+
+	if err := f.MoveToCgroup(int(dir.Fd())); err != nil {
+		t.Fatalf("failed to move to cgroup: %v", err)
+	}
+
+	testReap(t, cmd)
+
+	if err := f.MoveToCgroup(int(dir.Fd())); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileCgroupID(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	// The child shares the test process's cgroup, whose ID is the inode number
+	// of its cgroup v2 directory.
+	var st unix.Stat_t
+	if err := unix.Stat(testCgroup2Dir(t), &st); err != nil {
+		t.Fatalf("failed to stat cgroup: %v", err)
+	}
+
+	id, err := f.CgroupID()
+	if err != nil {
+		t.Fatalf("failed to get cgroup ID: %v", err)
+	}
+	if diff := cmp.Diff(st.Ino, id); diff != "" {
+		t.Fatalf("unexpected cgroup ID (-want +got):\n%s", diff)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.CgroupID(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+// testCgroup2Dir returns the cgroup v2 directory of the test process, or
+// skips the test if there is none.
+func testCgroup2Dir(t *testing.T) string {
+	t.Helper()
+
+	b, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		t.Fatalf("failed to read cgroup: %v", err)
+	}
+
+	var path string
+	for _, line := range strings.Split(string(b), "\n") {
+		if p, ok := strings.CutPrefix(line, "0::"); ok {
+			path = p
+		}
+	}
+
+	b, err = os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		t.Fatalf("failed to read mountinfo: %v", err)
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		// The filesystem type follows the " - " separator.
+		_, post, ok := strings.Cut(line, " - ")
+		if !ok || !strings.HasPrefix(post, "cgroup2 ") {
+			continue
+		}
+
+		// The mount may expose only a subtree of the hierarchy, rooted at the
+		// fourth field.
+		fields := strings.Fields(line)
+		if path == "" || len(fields) < 5 {
+			continue
+		}
+		if root := fields[3]; root != "/" {
+			rel, ok := strings.CutPrefix(path, root)
+			if !ok || (rel != "" && rel[0] != '/') {
+				continue
+			}
+			path = rel
+		}
+
+		return filepath.Join(fields[4], path)
+	}
+
+	t.Skip("skipping, no cgroup v2 hierarchy")
+	return ""
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package pidfd
+
+import "context"
+
+func (*File) freeze(_ context.Context) error { return errUnimplemented }
+func (*File) thaw() error                    { return errUnimplemented }
+func (*File) moveToCgroup(_ int) error       { return errUnimplemented }
+func (*File) cgroupID() (uint64, error)      { return 0, errUnimplemented }
@@ -0,0 +1,67 @@
+package pidfd
+
+import (
+	"errors"
+	"os"
+)
+
+// An OpenFailure classifies the reason that Open failed.
+type OpenFailure int
+
+// Possible OpenFailure values.
+const (
+	// OpenFailureOther indicates a failure which is not otherwise classified.
+	OpenFailureOther OpenFailure = iota
+
+	// OpenFailureNotExist indicates the process does not exist.
+	OpenFailureNotExist
+
+	// OpenFailurePermission indicates the caller lacks permission to open the
+	// process.
+	OpenFailurePermission
+
+	// OpenFailureUnsupported indicates pidfds are not supported by the
+	// operating system or kernel.
+	OpenFailureUnsupported
+
+	// OpenFailureFDExhausted indicates the file descriptor limit was reached.
+	OpenFailureFDExhausted
+)
+
+// String returns the string representation of an OpenFailure.
+func (o OpenFailure) String() string {
+	switch o {
+	case OpenFailureOther:
+		return "other"
+	case OpenFailureNotExist:
+		return "not exist"
+	case OpenFailurePermission:
+		return "permission"
+	case OpenFailureUnsupported:
+		return "unsupported"
+	case OpenFailureFDExhausted:
+		return "file descriptors exhausted"
+	default:
+		return "unknown"
+	}
+}
+
+// ClassifyOpenError classifies a non-nil error returned by Open into an
+// OpenFailure so that callers can decide how to proceed. Errors which are not
+// otherwise classified, including a nil error, are reported as OpenFailureOther.
+func ClassifyOpenError(err error) OpenFailure {
+	switch {
+	case err == nil:
+		return OpenFailureOther
+	case errors.Is(err, os.ErrNotExist):
+		return OpenFailureNotExist
+	case errors.Is(err, os.ErrPermission):
+		return OpenFailurePermission
+	case errors.Is(err, enosys):
+		return OpenFailureUnsupported
+	case errors.Is(err, ErrFDExhausted):
+		return OpenFailureFDExhausted
+	default:
+		return OpenFailureOther
+	}
+}
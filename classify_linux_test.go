@@ -0,0 +1,90 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestClassifyOpenError(t *testing.T) {
+	t.Parallel()
+
+	wrap := func(errno unix.Errno) error {
+		return &pidfd.Error{
+			PID: 1,
+			Err: os.NewSyscallError("pidfd_open", errno),
+		}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want pidfd.OpenFailure
+	}{
+		{
+			name: "nil",
+			want: pidfd.OpenFailureOther,
+		},
+		{
+			name: "other",
+			err:  errors.New("other"),
+			want: pidfd.OpenFailureOther,
+		},
+		{
+			name: "ESRCH",
+			err:  wrap(unix.ESRCH),
+			want: pidfd.OpenFailureNotExist,
+		},
+		{
+			name: "EPERM",
+			err:  wrap(unix.EPERM),
+			want: pidfd.OpenFailurePermission,
+		},
+		{
+			name: "ENOSYS",
+			err:  wrap(unix.ENOSYS),
+			want: pidfd.OpenFailureUnsupported,
+		},
+		{
+			name: "EMFILE",
+			err:  wrap(unix.EMFILE),
+			want: pidfd.OpenFailureFDExhausted,
+		},
+		{
+			name: "ENFILE",
+			err:  wrap(unix.ENFILE),
+			want: pidfd.OpenFailureFDExhausted,
+		},
+		{
+			name: "EINVAL",
+			err:  wrap(unix.EINVAL),
+			want: pidfd.OpenFailureOther,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, pidfd.ClassifyOpenError(tt.err)); diff != "" {
+				t.Fatalf("unexpected OpenFailure (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClassifyOpenErrorNotExist(t *testing.T) {
+	t.Parallel()
+
+	_, err := pidfd.Open(12345678)
+	if diff := cmp.Diff(pidfd.OpenFailureNotExist, pidfd.ClassifyOpenError(err)); diff != "" {
+		t.Fatalf("unexpected OpenFailure (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,111 @@
+package pidfd
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"sync/atomic"
+)
+
+// Command is like exec.CommandContext, but it also starts the command and
+// returns a File referring to the started process. See Start for details. To
+// configure the command further or to pass StartOptions, use
+// exec.CommandContext and Start instead. Command has the same requirements as
+// Start.
+func Command(ctx context.Context, name string, arg ...string) (*exec.Cmd, *File, error) {
+	cmd := exec.CommandContext(ctx, name, arg...)
+	f, err := Start(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cmd, f, nil
+}
+
+// Start starts cmd and returns a File referring to the started process. The
+// caller must still call cmd.Wait to release the process's resources.
+//
+// The pidfd is opened immediately after the process starts. The File cannot
+// refer to an unrelated process: the child's pid cannot be reused until the
+// child is reaped by cmd.Wait.
+//
+// Namespaces and other clone(2) options for the new process may be set using
+// cmd.SysProcAttr.Cloneflags. The Go runtime does not support running Go code
+// in a forked child which has not called execve(2), so there is no way to
+// obtain a File for a fork-only child; instead, re-execute the current binary
+// (os.Executable) with arguments which select the code to run in the child.
+//
+// If cmd.SysProcAttr.PidFD is set, the caller retains ownership of that pidfd
+// and a separate one is opened for the File.
+//
+// The File may be waited on as soon as Start returns. Start returns only after
+// the process has successfully executed cmd.Path, so failure to execute the
+// program, such as a nonexistent binary, is reported as an error by Start
+// rather than as an exit status. Programs which execute other programs, such
+// as shells, conventionally report such failures with exit code 127 for a
+// program which was not found and 126 for one which could not be executed.
+//
+// If the File cannot be created, the started process is killed and reaped.
+//
+// Start requires Linux 5.3+ for pidfd_open(2), and Linux 5.10+ for the
+// PIDFD_NONBLOCK flag with which the pidfd is opened; on older kernels, the
+// process is started and then killed and reaped, and an error is returned. On
+// other platforms, Start returns an error without starting the process. Start
+// works with any Go version supported by this package: it does not use
+// syscall.SysProcAttr.PidFD, which requires Go 1.22+.
+func Start(cmd *exec.Cmd, opts ...StartOption) (*File, error) {
+	var o startOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// The File does not exist until the process has started, but cmd.Cancel
+	// must be set beforehand.
+	var file atomic.Pointer[File]
+	if sig := o.cancelSignal; sig != nil {
+		cmd.Cancel = func() error {
+			err := os.ErrClosed
+			if f := file.Load(); f != nil {
+				err = f.SendSignal(sig)
+			}
+			if errors.Is(err, os.ErrClosed) {
+				// No usable File, but the process cannot have been reaped
+				// because exec.Cmd.Wait has not returned.
+				err = cmd.Process.Signal(sig)
+			}
+			if errors.Is(err, os.ErrNotExist) {
+				err = os.ErrProcessDone
+			}
+
+			return err
+		}
+	}
+
+	f, err := start(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	file.Store(f)
+	return f, nil
+}
+
+// A StartOption configures Start.
+type StartOption func(*startOptions)
+
+// startOptions contains the configuration set by StartOptions.
+type startOptions struct {
+	cancelSignal os.Signal
+}
+
+// WithCancelSignal configures Start to send sig to the process using its File
+// when the context of the command is done, rather than killing it with
+// SIGKILL. This allows the process to shut down gracefully on cancelation;
+// use exec.Cmd.WaitDelay to bound how long a graceful shutdown may take.
+//
+// WithCancelSignal overrides exec.Cmd.Cancel, so the command must be created
+// with exec.CommandContext.
+func WithCancelSignal(sig os.Signal) StartOption {
+	return func(o *startOptions) { o.cancelSignal = sig }
+}
@@ -0,0 +1,64 @@
+//go:build linux && go1.22
+
+package pidfd_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestStartSysProcAttrPidFD(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	// The caller requests a pidfd of their own with CLONE_PIDFD.
+	fd := -1
+	cmd := exec.Command("sleep", "3600")
+	cmd.SysProcAttr = &syscall.SysProcAttr{PidFD: &fd}
+
+	f, err := pidfd.Start(cmd)
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+
+	if fd < 0 {
+		t.Skip("skipping, CLONE_PIDFD is not supported")
+	}
+	defer unix.Close(fd)
+
+	// The File uses a separate pidfd, and the caller's pidfd is left blocking.
+	if int(f.EpollEvent().Fd) == fd {
+		t.Fatal("File must not use the caller's pidfd")
+	}
+
+	flags, err := unix.FcntlInt(uintptr(fd), unix.F_GETFL, 0)
+	if err != nil {
+		t.Fatalf("failed to get pidfd flags: %v", err)
+	}
+	if flags&unix.O_NONBLOCK != 0 {
+		t.Fatal("caller's pidfd was made nonblocking")
+	}
+
+	// Both pidfds refer to the same process.
+	if err := unix.PidfdSendSignal(fd, unix.SIGKILL, nil, 0); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for child process exit: %v", err)
+	}
+
+	var eerr *exec.ExitError
+	if err := cmd.Wait(); !errors.As(err, &eerr) {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+}
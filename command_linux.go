@@ -0,0 +1,26 @@
+//go:build linux
+
+package pidfd
+
+import "os/exec"
+
+// start implements Start.
+func start(cmd *exec.Cmd) (*File, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// Open a pidfd of our own rather than using CLONE_PIDFD: on Go 1.23+,
+	// os.Process holds a duplicate of any pidfd set in SysProcAttr.PidFD,
+	// and setting O_NONBLOCK on that shared open file description would break
+	// cmd.Wait. The child cannot be reaped by anyone but us until cmd.Wait is
+	// called, so opening it by pid is race-free.
+	f, err := open(cmd.Process.Pid)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, err
+	}
+
+	return f, nil
+}
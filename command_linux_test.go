@@ -0,0 +1,129 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	cmd, f, err := pidfd.Command(ctx, "sleep", "1")
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for child process exit: %v", err)
+	}
+
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+}
+
+func TestStartCmdWait(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sleep", "0.3")
+	f, err := pidfd.Start(cmd)
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+
+	// cmd.Wait must block until the still running child exits and then reap
+	// it, regardless of the File's own nonblocking pidfd.
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+}
+
+func TestStartExecFailure(t *testing.T) {
+	t.Parallel()
+
+	// The path contains a separator, so exec.Command does not search PATH and
+	// the failure is reported when executing the program.
+	cmd := exec.Command("/nonexistent/pidfd-test")
+
+	f, err := pidfd.Start(cmd)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+	if f != nil {
+		t.Fatal("expected nil File for failed command")
+	}
+}
+
+func TestStartExecFailureShell(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	// A shell reports that the program it executes was not found with exit
+	// code 127, which the File observes after the shell itself has started.
+	cmd, f, err := pidfd.Command(ctx, "sh", "-c", "exec /nonexistent/pidfd-test 2>/dev/null")
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+
+	info, _, err := f.WaitIO(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for child process exit: %v", err)
+	}
+
+	if !info.Exited() || info.ExitCode() != 127 {
+		t.Fatalf("expected exit code 127, but got: %+v", info)
+	}
+
+	var eerr *exec.ExitError
+	if err := cmd.Wait(); !errors.As(err, &eerr) || eerr.ExitCode() != 127 {
+		t.Fatalf("expected exit code 127 from cmd.Wait, but got: %v", err)
+	}
+}
+
+func TestStartWithCancelSignal(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	cctx, ccancel := context.WithCancel(ctx)
+	defer ccancel()
+
+	cmd := exec.CommandContext(cctx, "sleep", "3600")
+	f, err := pidfd.Start(cmd, pidfd.WithCancelSignal(unix.SIGTERM))
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+	defer func() { _ = cmd.Wait() }()
+
+	// Canceling the command's context sends SIGTERM rather than SIGKILL.
+	ccancel()
+
+	wi, _, err := f.WaitIO(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGTERM), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+}
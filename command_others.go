@@ -0,0 +1,7 @@
+//go:build !linux
+
+package pidfd
+
+import "os/exec"
+
+func start(_ *exec.Cmd) (*File, error) { return nil, errUnimplemented }
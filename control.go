@@ -0,0 +1,21 @@
+package pidfd
+
+// ControlMessage returns a socket control message which passes the File's
+// pidfd over a Unix socket using SCM_RIGHTS, for callers which perform their
+// own socket writes. The pidfd is only valid until the File is closed, so the
+// File must remain open until the message is sent. If the File is already
+// closed, ControlMessage returns nil.
+func (f *File) ControlMessage() []byte { return f.controlMessage() }
+
+// FileFromControlMessage creates a File from socket control messages b, as
+// produced by ControlMessage and received from a Unix socket. The messages
+// must contain exactly one file descriptor, which must be a pidfd referring
+// to the process identified by pid in the caller's pid namespace. Otherwise,
+// any received file descriptors are closed and an error is returned.
+//
+// The received pidfd shares its open file description with the sender's
+// pidfd, so the File uses a pidfd of its own for the same process instead,
+// leaving the sender's pidfd flags unchanged. The received pidfd is closed.
+func FileFromControlMessage(b []byte, pid int) (*File, error) {
+	return fileFromControlMessage(b, pid)
+}
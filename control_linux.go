@@ -0,0 +1,120 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// controlMessage implements File.ControlMessage.
+func (f *File) controlMessage() []byte {
+	var b []byte
+	_ = f.rc.Control(func(fd uintptr) {
+		b = unix.UnixRights(int(fd))
+	})
+
+	return b
+}
+
+// fileFromControlMessage implements FileFromControlMessage.
+func fileFromControlMessage(b []byte, pid int) (*File, error) {
+	scms, err := unix.ParseSocketControlMessage(b)
+	if err != nil {
+		return nil, os.NewSyscallError("parse control message", err)
+	}
+
+	var (
+		fds  []int
+		perr error
+	)
+	for _, scm := range scms {
+		rights, err := unix.ParseUnixRights(&scm)
+		if err != nil {
+			// Not SCM_RIGHTS, but keep collecting any fds to close them.
+			perr = err
+			continue
+		}
+
+		fds = append(fds, rights...)
+	}
+
+	if perr != nil || len(fds) != 1 {
+		for _, fd := range fds {
+			_ = unix.Close(fd)
+		}
+
+		if perr != nil {
+			return nil, os.NewSyscallError("parse unix rights", perr)
+		}
+
+		return nil, fmt.Errorf("pidfd: control message must contain exactly 1 file descriptor, but got %d", len(fds))
+	}
+
+	// The sender may not have set MSG_CMSG_CLOEXEC.
+	fd := fds[0]
+	unix.CloseOnExec(fd)
+
+	if err := checkPidFD(fd, pid); err != nil {
+		_ = unix.Close(fd)
+		return nil, err
+	}
+
+	// Open a pidfd of our own rather than using the received one: it shares
+	// the sender's open file description, and socket.New would set
+	// O_NONBLOCK on the sender's pidfd as well.
+	f, err := open(pid)
+	if err != nil {
+		_ = unix.Close(fd)
+		return nil, err
+	}
+
+	// The pid cannot have been reused before it was opened if the process
+	// referred to by the received pidfd still has not been reaped.
+	err = checkPidFD(fd, pid)
+	_ = unix.Close(fd)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// checkPidFD checks that fd is a pidfd referring to the process identified by
+// pid, using the Pid field reported by /proc/self/fdinfo for pidfds.
+func checkPidFD(fd, pid int) error {
+	b, err := os.ReadFile("/proc/self/fdinfo/" + strconv.Itoa(fd))
+	if err != nil {
+		return &Error{PID: pid, FD: fd, Err: err}
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if !ok || k != "Pid" {
+			continue
+		}
+
+		got, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return &Error{PID: pid, FD: fd, Err: err}
+		}
+
+		switch got {
+		case pid:
+			return nil
+		case -1:
+			// The process has exited and been reaped.
+			return &Error{PID: pid, FD: fd, Err: os.ErrNotExist}
+		default:
+			return &Error{PID: pid, FD: fd, Err: fmt.Errorf("pidfd refers to pid %d", got)}
+		}
+	}
+
+	return &Error{PID: pid, FD: fd, Err: errors.New("file descriptor is not a pidfd")}
+}
@@ -0,0 +1,163 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestFileControlMessage(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatalf("failed to create socket pair: %v", err)
+	}
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
+
+	if err := unix.Sendmsg(fds[0], []byte{0}, f.ControlMessage(), nil, 0); err != nil {
+		t.Fatalf("failed to send control message: %v", err)
+	}
+
+	var (
+		p   = make([]byte, 1)
+		oob = make([]byte, unix.CmsgSpace(4))
+	)
+	_, oobn, _, _, err := unix.Recvmsg(fds[1], p, oob, unix.MSG_CMSG_CLOEXEC)
+	if err != nil {
+		t.Fatalf("failed to receive control message: %v", err)
+	}
+
+	rf, err := pidfd.FileFromControlMessage(oob[:oobn], cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to create File from control message: %v", err)
+	}
+	defer rf.Close()
+
+	// The received File refers to the same process.
+	if err := rf.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+}
+
+func TestFileFromControlMessageSenderFlags(t *testing.T) {
+	t.Parallel()
+
+	_, _, cmd := testSleepFile(t, 1*time.Hour)
+
+	// A blocking pidfd, as a sender which does not use this package might
+	// pass.
+	sender, err := unix.PidfdOpen(cmd.Process.Pid, 0)
+	if err != nil {
+		t.Fatalf("failed to open pidfd: %v", err)
+	}
+	defer unix.Close(sender)
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatalf("failed to create socket pair: %v", err)
+	}
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
+
+	if err := unix.Sendmsg(fds[0], []byte{0}, unix.UnixRights(sender), nil, 0); err != nil {
+		t.Fatalf("failed to send control message: %v", err)
+	}
+
+	var (
+		p   = make([]byte, 1)
+		oob = make([]byte, unix.CmsgSpace(4))
+	)
+	_, oobn, _, _, err := unix.Recvmsg(fds[1], p, oob, unix.MSG_CMSG_CLOEXEC)
+	if err != nil {
+		t.Fatalf("failed to receive control message: %v", err)
+	}
+
+	rf, err := pidfd.FileFromControlMessage(oob[:oobn], cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to create File from control message: %v", err)
+	}
+	defer rf.Close()
+
+	// The sender's pidfd must remain blocking.
+	flags, err := unix.FcntlInt(uintptr(sender), unix.F_GETFL, 0)
+	if err != nil {
+		t.Fatalf("failed to get sender flags: %v", err)
+	}
+	if flags&unix.O_NONBLOCK != 0 {
+		t.Fatal("sender's pidfd was made nonblocking")
+	}
+}
+
+func TestFileFromControlMessageErrors(t *testing.T) {
+	t.Parallel()
+
+	null, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatalf("failed to open null device: %v", err)
+	}
+	defer null.Close()
+
+	// Pass duplicates of a file descriptor, which are closed on error.
+	dup := func() int {
+		fd, err := unix.Dup(int(null.Fd()))
+		if err != nil {
+			t.Fatalf("failed to duplicate file descriptor: %v", err)
+		}
+
+		return fd
+	}
+
+	// A pidfd for the test process, which is not the pid the File expects.
+	self, err := unix.PidfdOpen(os.Getpid(), 0)
+	if err != nil {
+		t.Skipf("skipping, failed to open pidfd: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		b    []byte
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "multiple",
+			b:    unix.UnixRights(dup(), dup()),
+		},
+		{
+			name: "malformed",
+			b:    []byte{0xff},
+		},
+		{
+			name: "not pidfd",
+			b:    unix.UnixRights(dup()),
+		},
+		{
+			name: "wrong pid",
+			b:    unix.UnixRights(self),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := pidfd.FileFromControlMessage(tt.b, 1); err == nil {
+				t.Fatal("expected an error, but none occurred")
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !linux
+
+package pidfd
+
+func (*File) controlMessage() []byte                        { return nil }
+func fileFromControlMessage(_ []byte, _ int) (*File, error) { return nil, errUnimplemented }
@@ -0,0 +1,27 @@
+package pidfd
+
+import (
+	"log"
+	"runtime"
+	"sync/atomic"
+)
+
+// finalizerWarnings reports whether SetFinalizerWarnings is enabled.
+var finalizerWarnings atomic.Bool
+
+// SetFinalizerWarnings enables or disables warnings for leaked Files. When
+// enabled, Files opened afterward log a warning with package log if they are
+// garbage collected without being closed. Warnings are disabled by default
+// and are intended to help find leaks during development.
+func SetFinalizerWarnings(enable bool) { finalizerWarnings.Store(enable) }
+
+// setFinalizer attaches a leak warning finalizer to f if enabled.
+func setFinalizer(f *File) {
+	if !finalizerWarnings.Load() {
+		return
+	}
+
+	runtime.SetFinalizer(f, func(f *File) {
+		log.Printf("pidfd: File for pid %d was garbage collected without being closed", f.pid)
+	})
+}
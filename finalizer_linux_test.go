@@ -0,0 +1,70 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/pidfd"
+)
+
+func TestSetFinalizerWarnings(t *testing.T) {
+	// Not parallel: both finalizer warnings and log output are package-wide.
+	var w syncWriter
+	log.SetOutput(&w)
+	defer log.SetOutput(os.Stderr)
+
+	pidfd.SetFinalizerWarnings(true)
+	defer pidfd.SetFinalizerWarnings(false)
+
+	// Closed Files must not produce warnings.
+	f, err := pidfd.Open(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to open pidfd: %v", err)
+	}
+	_ = f.Close()
+
+	if _, err := pidfd.Open(1); err != nil {
+		t.Fatalf("failed to open leaked pidfd: %v", err)
+	}
+
+	// Finalizers run asynchronously after garbage collection.
+	deadline := time.Now().Add(10 * time.Second)
+	for !strings.Contains(w.String(), "pid 1 ") {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for finalizer warning")
+		}
+
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if strings.Contains(w.String(), "pid "+strconv.Itoa(os.Getpid())+" ") {
+		t.Fatalf("unexpected warning for closed File: %s", w.String())
+	}
+}
+
+type syncWriter struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (w *syncWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.Write(b)
+}
+
+func (w *syncWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.b.String()
+}
@@ -0,0 +1,265 @@
+package pidfd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"syscall"
+)
+
+// A Group waits for the exit of any process in a dynamic set of Files. Files
+// may be added to and removed from a Group at any time, including while Wait
+// is in progress. All methods are safe for concurrent use.
+type Group struct {
+	c  *conn
+	rc syscall.RawConn
+
+	// mu protects the Files in the Group, indexed by ID and by File.
+	mu     sync.Mutex
+	nextID uint64
+	files  map[uint64]*File
+	ids    map[*File]uint64
+}
+
+// NewGroup creates an empty Group.
+func NewGroup() (*Group, error) { return newGroup() }
+
+// Close releases the Group's resources. It does not close any Files in the
+// Group.
+func (g *Group) Close() error { return g.c.Close() }
+
+// Add adds f to the Group. f must be removed from the Group before f is
+// closed.
+func (g *Group) Add(f *File) error { return g.add(f) }
+
+// Remove removes f from the Group. After Remove returns, Wait will not return
+// f, even if Wait was already in progress and f's process has exited. Remove
+// is a no-op if f is not in the Group.
+func (g *Group) Remove(f *File) error { return g.remove(f) }
+
+// Wait waits for the process referred to by any File in the Group to exit,
+// and returns that File. The returned File is removed from the Group, so each
+// exit is reported exactly once. If the Group is empty, Wait blocks until a
+// File is added.
+//
+// If the context is canceled, Wait will unblock and return an error.
+func (g *Group) Wait(ctx context.Context) (*File, error) { return g.wait(ctx) }
+
+// Drain reaps every process in the Group which has already exited and
+// returns their exit information, removing the Files for those processes from
+// the Group. Drain does not block: if no process in the Group has exited, it
+// returns an empty slice and nil error. It is intended for a caller such as a
+// container init process which must reap a burst of exits with as little
+// per-exit overhead as possible.
+//
+// Ready Files are collected in batches with a single epoll_wait(2) call rather
+// than waiting for each File in turn. Each exit is then reaped with waitid(2)
+// on its pidfd; Drain never uses P_ALL or P_PGID, which would also reap
+// children outside of the Group.
+//
+// Unlike Wait and Peek, which leave the process in a waitable state with
+// WNOWAIT, Drain consumes each exit it reports. Subsequent calls to Wait on
+// a drained File will fail because the process has already been reaped. Only
+// children of the caller can be reaped: Files for other processes are left in
+// the Group and must be removed with Wait or Remove.
+//
+// If an error occurs, Drain returns the exits reaped so far along with the
+// error.
+func (g *Group) Drain() ([]*WaitInfo, error) { return g.drain() }
+
+// WaitAll waits for the processes referred to by every File in the Group to
+// exit. It is typically used to drain a Group at shutdown after signaling its
+// processes. Files added while WaitAll is in progress are not waited for.
+//
+// Unlike Wait, WaitAll does not remove any Files from the Group, so exits
+// observed by WaitAll are still reported by Wait.
+//
+// If the context is canceled before every process exits, WaitAll returns a
+// *WaitAllError containing the pids of the processes which are still alive,
+// in the order their Files were added to the Group.
+func (g *Group) WaitAll(ctx context.Context) error {
+	// Report pids in a stable order: IDs are assigned in the order Files were
+	// added.
+	g.mu.Lock()
+	ids := make([]uint64, 0, len(g.files))
+	for id := range g.files {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	files := make([]*File, 0, len(ids))
+	for _, id := range ids {
+		files = append(files, g.files[id])
+	}
+	g.mu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(files))
+	)
+
+	wg.Add(len(files))
+	for i, f := range files {
+		go func(i int, f *File) {
+			defer wg.Done()
+			errs[i] = f.waitReadable(ctx)
+		}(i, f)
+	}
+	wg.Wait()
+
+	var pids []int
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, ctx.Err()):
+			pids = append(pids, files[i].pid)
+		default:
+			return err
+		}
+	}
+	if len(pids) > 0 {
+		return &WaitAllError{PIDs: pids, Err: ctx.Err()}
+	}
+
+	return nil
+}
+
+// A WaitAllError is returned by Group.WaitAll when its context is canceled
+// before all processes in the Group exit.
+type WaitAllError struct {
+	// PIDs are the pids of the processes which had not exited, in the order
+	// their Files were added to the Group.
+	PIDs []int
+
+	// Err is the context's error.
+	Err error
+}
+
+// Error implements error.
+func (e *WaitAllError) Error() string {
+	return fmt.Sprintf("pidfd: processes still alive: %v: %v", e.PIDs, e.Err)
+}
+
+// Unwrap implements errors.Unwrap functionality.
+func (e *WaitAllError) Unwrap() error { return e.Err }
+
+// WaitN waits for the processes referred to by n of files to exit, and returns
+// the indices of those files in files in the order their exits were observed.
+// WaitN with n of 1 waits for any process to exit, and WaitN with n of
+// len(files) waits for all of them.
+//
+// If the context is canceled before n processes exit, WaitN will unblock and
+// return the indices of the files observed so far along with an error.
+func WaitN(ctx context.Context, n int, files ...*File) ([]int, error) {
+	if n < 0 || n > len(files) {
+		return nil, fmt.Errorf("pidfd: WaitN count %d out of range for %d Files", n, len(files))
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	g, err := NewGroup()
+	if err != nil {
+		return nil, err
+	}
+	defer g.Close()
+
+	idx := make(map[*File]int, len(files))
+	for i, f := range files {
+		if err := g.Add(f); err != nil {
+			return nil, err
+		}
+		idx[f] = i
+	}
+
+	// Files must be removed before the caller may close them.
+	defer func() {
+		for _, f := range files {
+			_ = g.Remove(f)
+		}
+	}()
+
+	done := make([]int, 0, n)
+	for len(done) < n {
+		f, err := g.Wait(ctx)
+		if err != nil {
+			return done, err
+		}
+
+		done = append(done, idx[f])
+	}
+
+	return done, nil
+}
+
+// A Waiter waits for a set of process exits and application events to
+// complete, similar to a sync.WaitGroup. The zero value is an empty Waiter
+// which is ready for use. All methods are safe for concurrent use.
+type Waiter struct {
+	mu    sync.Mutex
+	files []*File
+	chans []<-chan struct{}
+}
+
+// AddFile adds f to the Waiter. Wait returns once the process referred to by
+// f has exited. The process need not be a child of the caller, and it is not
+// reaped.
+func (w *Waiter) AddFile(f *File) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files = append(w.files, f)
+}
+
+// AddChan adds ch to the Waiter. Wait returns once ch has been closed or has
+// delivered a value.
+func (w *Waiter) AddChan(ch <-chan struct{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.chans = append(w.chans, ch)
+}
+
+// Wait waits for every File and channel added to the Waiter to complete.
+// Files and channels added while Wait is in progress are not waited for.
+//
+// If the context is canceled, Wait will unblock and return an error.
+func (w *Waiter) Wait(ctx context.Context) error {
+	w.mu.Lock()
+	files := append([]*File(nil), w.files...)
+	chans := append([]<-chan struct{}(nil), w.chans...)
+	w.mu.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(files)+len(chans))
+	)
+
+	wg.Add(len(errs))
+	for i, f := range files {
+		go func(i int, f *File) {
+			defer wg.Done()
+			errs[i] = f.waitReadable(ctx)
+		}(i, f)
+	}
+	for i, ch := range chans {
+		go func(i int, ch <-chan struct{}) {
+			defer wg.Done()
+			select {
+			case <-ch:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+			}
+		}(len(files)+i, ch)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mdlayher/socket"
+	"golang.org/x/sys/unix"
+)
+
+// newGroup creates a Group backed by epoll.
+func newGroup() (*Group, error) {
+	fd, err := unix.EpollCreate1(unix.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, os.NewSyscallError("epoll_create1", err)
+	}
+
+	// An epoll file descriptor is itself readable when any of its file
+	// descriptors are ready, so it can be used with the runtime poller.
+	c, err := socket.New(fd, "epoll")
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := c.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Group{
+		c:     c,
+		rc:    rc,
+		files: make(map[uint64]*File),
+		ids:   make(map[*File]uint64),
+	}, nil
+}
+
+// add implements Group.Add.
+func (g *Group) add(f *File) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.ids[f]; ok {
+		return fmt.Errorf("pidfd: File for pid %d is already in Group", f.pid)
+	}
+
+	// Identify Files by an ID rather than by file descriptor, so that events
+	// for a removed File can never be attributed to a new File which reuses
+	// its file descriptor number. IDs are 64 bits wide and so never wrap.
+	id := g.nextID
+	g.nextID++
+
+	// A pidfd becomes readable when its process exits.
+	ev := epollEvent(id)
+	if err := g.epollCtl(f, unix.EPOLL_CTL_ADD, &ev); err != nil {
+		return err
+	}
+
+	g.files[id] = f
+	g.ids[f] = id
+	return nil
+}
+
+// remove implements Group.Remove.
+func (g *Group) remove(f *File) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.removeLocked(f)
+}
+
+// removeLocked removes f from the Group. g.mu must be held.
+func (g *Group) removeLocked(f *File) error {
+	id, ok := g.ids[f]
+	if !ok {
+		return nil
+	}
+
+	delete(g.files, id)
+	delete(g.ids, f)
+
+	return g.epollCtl(f, unix.EPOLL_CTL_DEL, nil)
+}
+
+// epollCtl performs epoll_ctl(2) op for the pidfd of f.
+func (g *Group) epollCtl(f *File, op int, ev *unix.EpollEvent) error {
+	var err error
+	cerr := g.rc.Control(func(epfd uintptr) {
+		ferr := f.rc.Control(func(fd uintptr) {
+			err = unix.EpollCtl(int(epfd), op, int(fd), ev)
+		})
+		if err == nil {
+			err = ferr
+		}
+	})
+	if cerr != nil {
+		return cerr
+	}
+
+	return f.wrap(os.NewSyscallError("epoll_ctl", err))
+}
+
+// wait implements Group.Wait.
+func (g *Group) wait(ctx context.Context) (*File, error) {
+	for {
+		id, err := g.epollWait(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		g.mu.Lock()
+		f, ok := g.files[id]
+		if !ok {
+			// Removed concurrently after the event was reported.
+			g.mu.Unlock()
+			continue
+		}
+
+		err = g.removeLocked(f)
+		g.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+
+		return f, nil
+	}
+}
+
+// epollWait waits for a single ready File and returns its ID.
+func (g *Group) epollWait(ctx context.Context) (uint64, error) {
+	var (
+		events [1]unix.EpollEvent
+		n      int
+		err    error
+	)
+
+	rerr := readContext(ctx, g.c, func() error {
+		return g.rc.Read(func(fd uintptr) bool {
+			// Never block in the system call, wait for the runtime poller
+			// to report readiness instead.
+			n, err = unix.EpollWait(int(fd), events[:], 0)
+			return !(n == 0 && err == nil) && err != unix.EINTR
+		})
+	})
+	if rerr != nil {
+		return 0, rerr
+	}
+	if err != nil {
+		return 0, os.NewSyscallError("epoll_wait", err)
+	}
+
+	return epollID(events[0]), nil
+}
+
+// epollEvent returns an EPOLLIN event carrying id in its 64-bit data field,
+// which unix.EpollEvent splits across Fd and Pad.
+func epollEvent(id uint64) unix.EpollEvent {
+	return unix.EpollEvent{
+		Events: unix.EPOLLIN,
+		Fd:     int32(uint32(id)),
+		Pad:    int32(uint32(id >> 32)),
+	}
+}
+
+// epollID returns the ID stored in ev by epollEvent.
+func epollID(ev unix.EpollEvent) uint64 {
+	return uint64(uint32(ev.Fd)) | uint64(uint32(ev.Pad))<<32
+}
+
+// drainBatch is the maximum number of ready Files collected by each
+// epoll_wait(2) call in drain.
+const drainBatch = 64
+
+// drain implements Group.Drain.
+func (g *Group) drain() ([]*WaitInfo, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var (
+		wis    []*WaitInfo
+		events [drainBatch]unix.EpollEvent
+	)
+
+	for {
+		n, err := g.epollReady(events[:])
+		if err != nil {
+			return wis, err
+		}
+
+		var reaped int
+		for _, ev := range events[:n] {
+			f, ok := g.files[epollID(ev)]
+			if !ok {
+				continue
+			}
+
+			wi, err := f.consume()
+			if err != nil {
+				if errors.Is(err, unix.ECHILD) {
+					// Not a child of the caller, so it can't be reaped.
+					continue
+				}
+
+				return wis, err
+			}
+			if wi == nil {
+				continue
+			}
+
+			if err := g.removeLocked(f); err != nil {
+				return wis, err
+			}
+
+			wis = append(wis, wi)
+			reaped++
+		}
+
+		// Files which can't be reaped remain ready, so stop once a batch makes
+		// no progress.
+		if n < len(events) || reaped == 0 {
+			return wis, nil
+		}
+	}
+}
+
+// epollReady reports the IDs of ready Files in events without blocking.
+func (g *Group) epollReady(events []unix.EpollEvent) (int, error) {
+	var (
+		n   int
+		err error
+	)
+	cerr := g.rc.Control(func(fd uintptr) {
+		for {
+			n, err = unix.EpollWait(int(fd), events, 0)
+			if err != unix.EINTR {
+				return
+			}
+		}
+	})
+	if cerr != nil {
+		return 0, cerr
+	}
+	if err != nil {
+		return 0, os.NewSyscallError("epoll_wait", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,318 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestGroupWait(t *testing.T) {
+	t.Parallel()
+
+	g := testGroup(t)
+
+	ctx, long, _ := testSleepFile(t, 1*time.Hour)
+	_, short, _ := testSleepFile(t, 1*time.Second)
+
+	for _, f := range []*pidfd.File{long, short} {
+		if err := g.Add(f); err != nil {
+			t.Fatalf("failed to add File: %v", err)
+		}
+	}
+
+	if err := g.Add(short); err == nil {
+		t.Fatal("expected error adding File twice, but none occurred")
+	}
+
+	f, err := g.Wait(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for Group: %v", err)
+	}
+	if f != short {
+		t.Fatal("expected short-lived process to exit first")
+	}
+
+	// The exited File was removed, so only the long-lived process remains.
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := g.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, but got: %v", err)
+	}
+}
+
+func TestGroupAddRemoveDuringWait(t *testing.T) {
+	t.Parallel()
+
+	g := testGroup(t)
+
+	ctx, removed, _ := testSleepFile(t, 1*time.Hour)
+	_, added, _ := testSleepFile(t, 1*time.Hour)
+
+	if err := g.Add(removed); err != nil {
+		t.Fatalf("failed to add File: %v", err)
+	}
+
+	type result struct {
+		f   *pidfd.File
+		err error
+	}
+
+	resC := make(chan result, 1)
+	go func() {
+		f, err := g.Wait(ctx)
+		resC <- result{f: f, err: err}
+	}()
+
+	// Concurrently swap the Files in the Group while Wait is in progress.
+	// The removed File's process exits, but must not be reported.
+	time.Sleep(50 * time.Millisecond)
+	if err := g.Add(added); err != nil {
+		t.Fatalf("failed to add File: %v", err)
+	}
+	if err := g.Remove(removed); err != nil {
+		t.Fatalf("failed to remove File: %v", err)
+	}
+	if err := removed.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal removed process: %v", err)
+	}
+	if err := removed.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for removed process: %v", err)
+	}
+
+	// Removing again is a no-op.
+	if err := g.Remove(removed); err != nil {
+		t.Fatalf("failed to remove File again: %v", err)
+	}
+
+	select {
+	case res := <-resC:
+		t.Fatalf("Wait returned early: %p, %v", res.f, res.err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if err := added.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal added process: %v", err)
+	}
+
+	res := <-resC
+	if res.err != nil {
+		t.Fatalf("failed to wait for Group: %v", res.err)
+	}
+	if res.f != added {
+		t.Fatal("expected added process to be reported")
+	}
+}
+
+func testGroup(t *testing.T) *pidfd.Group {
+	t.Helper()
+
+	g, err := pidfd.NewGroup()
+	if err != nil {
+		t.Fatalf("failed to create Group: %v", err)
+	}
+	t.Cleanup(func() { _ = g.Close() })
+
+	return g
+}
+
+func TestGroupWaitAll(t *testing.T) {
+	t.Parallel()
+
+	g := testGroup(t)
+
+	ctx, f1, _ := testSleepFile(t, 1*time.Second)
+	_, f2, _ := testSleepFile(t, 1*time.Second)
+
+	for _, f := range []*pidfd.File{f1, f2} {
+		if err := g.Add(f); err != nil {
+			t.Fatalf("failed to add File: %v", err)
+		}
+	}
+
+	if err := g.WaitAll(ctx); err != nil {
+		t.Fatalf("failed to wait for all processes: %v", err)
+	}
+
+	// WaitAll does not consume exits reported by Wait.
+	for i := 0; i < 2; i++ {
+		if _, err := g.Wait(ctx); err != nil {
+			t.Fatalf("failed to wait for Group[%d]: %v", i, err)
+		}
+	}
+}
+
+func TestGroupWaitAllStillAlive(t *testing.T) {
+	t.Parallel()
+
+	g := testGroup(t)
+
+	ctx, exited, _ := testSleepFile(t, 1*time.Hour)
+	_, alive1, cmd1 := testSleepFile(t, 1*time.Hour)
+	_, alive2, cmd2 := testSleepFile(t, 1*time.Hour)
+
+	// Alive pids are reported in the order their Files were added.
+	for _, f := range []*pidfd.File{exited, alive2, alive1} {
+		if err := g.Add(f); err != nil {
+			t.Fatalf("failed to add File: %v", err)
+		}
+	}
+
+	if err := exited.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+
+	var werr *pidfd.WaitAllError
+	err := g.WaitAll(ctx)
+	if !errors.As(err, &werr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected *pidfd.WaitAllError with deadline exceeded, but got: %v", err)
+	}
+
+	want := []int{cmd2.Process.Pid, cmd1.Process.Pid}
+	if diff := cmp.Diff(want, werr.PIDs); diff != "" {
+		t.Fatalf("unexpected alive pids (-want +got):\n%s", diff)
+	}
+}
+
+func TestWaitN(t *testing.T) {
+	t.Parallel()
+
+	ctx, long, _ := testSleepFile(t, 1*time.Hour)
+	_, short, _ := testSleepFile(t, 100*time.Millisecond)
+	_, medium, _ := testSleepFile(t, 1*time.Second)
+
+	files := []*pidfd.File{long, short, medium}
+
+	if _, err := pidfd.WaitN(ctx, 4, files...); err == nil {
+		t.Fatal("expected error for out of range count, but none occurred")
+	}
+
+	idx, err := pidfd.WaitN(ctx, 2, files...)
+	if err != nil {
+		t.Fatalf("failed to wait for 2 Files: %v", err)
+	}
+	if diff := cmp.Diff([]int{1, 2}, idx); diff != "" {
+		t.Fatalf("unexpected indices (-want +got):\n%s", diff)
+	}
+
+	// The long-lived process never exits, so waiting for all of them times
+	// out after observing the two exited processes again.
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	idx, err = pidfd.WaitN(ctx, len(files), files...)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, but got: %v", err)
+	}
+	if len(idx) != 2 {
+		t.Fatalf("expected 2 exited Files, but got: %v", idx)
+	}
+}
+
+func TestGroupDrain(t *testing.T) {
+	t.Parallel()
+
+	g := testGroup(t)
+
+	wis, err := g.Drain()
+	if err != nil {
+		t.Fatalf("failed to drain empty Group: %v", err)
+	}
+	if len(wis) != 0 {
+		t.Fatalf("expected no exits, but got: %d", len(wis))
+	}
+
+	ctx, f1, cmd1 := testSleepFile(t, 1*time.Hour)
+	_, f2, cmd2 := testSleepFile(t, 1*time.Hour)
+	_, alive, _ := testSleepFile(t, 1*time.Hour)
+
+	for _, f := range []*pidfd.File{f1, f2, alive} {
+		if err := g.Add(f); err != nil {
+			t.Fatalf("failed to add File: %v", err)
+		}
+	}
+
+	for _, f := range []*pidfd.File{f1, f2} {
+		if err := f.SendSignal(unix.SIGKILL); err != nil {
+			t.Fatalf("failed to signal child process: %v", err)
+		}
+
+		// Wait leaves the process waitable for Drain.
+		if err := f.Wait(ctx); err != nil {
+			t.Fatalf("failed to wait for child process: %v", err)
+		}
+	}
+
+	wis, err = g.Drain()
+	if err != nil {
+		t.Fatalf("failed to drain Group: %v", err)
+	}
+
+	pids := make(map[int]bool)
+	for _, wi := range wis {
+		if !wi.Signaled() {
+			t.Fatalf("expected process to be signaled: %+v", wi)
+		}
+		pids[wi.WaitedPID] = true
+	}
+
+	want := map[int]bool{cmd1.Process.Pid: true, cmd2.Process.Pid: true}
+	if diff := cmp.Diff(want, pids); diff != "" {
+		t.Fatalf("unexpected drained pids (-want +got):\n%s", diff)
+	}
+
+	// Drained Files were removed, so only the live process remains.
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := g.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, but got: %v", err)
+	}
+}
+
+func TestWaiter(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+	ch := make(chan struct{})
+
+	var w pidfd.Waiter
+	w.AddFile(f)
+	w.AddChan(ch)
+
+	// Neither the process nor the channel are complete.
+	tctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	if err := w.Wait(tctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, but got: %v", err)
+	}
+
+	if err := f.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	// The process has exited, but the channel is not complete.
+	tctx, cancel = context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	if err := w.Wait(tctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, but got: %v", err)
+	}
+
+	close(ch)
+
+	if err := w.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait: %v", err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package pidfd
+
+import "context"
+
+func newGroup() (*Group, error)                      { return nil, errUnimplemented }
+func (*Group) add(_ *File) error                     { return errUnimplemented }
+func (*Group) remove(_ *File) error                  { return errUnimplemented }
+func (*Group) wait(_ context.Context) (*File, error) { return nil, errUnimplemented }
+func (*Group) drain() ([]*WaitInfo, error)           { return nil, errUnimplemented }
@@ -0,0 +1,107 @@
+package pidfd
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrIdentityMismatch is returned via errors.Is when a process's identity does
+// not match an expected Identity, indicating that a pid now refers to a
+// different process.
+var ErrIdentityMismatch = errors.New("pidfd: process identity mismatch")
+
+// An Identity identifies a process independently of its pid, which may be
+// reused after the process exits. Identity values are comparable and may be
+// serialized by callers, for example to verify that a pid recorded in a file
+// still refers to the same process after a restart.
+type Identity struct {
+	// PID is the process ID at the time the Identity was created.
+	PID int
+
+	// Inode is the inode number of the process's pidfd. On Linux 6.9+, which
+	// back pidfds with pidfs, the inode number is unique for each process for
+	// the lifetime of the system. On older kernels, Inode is zero.
+	Inode uint64
+
+	// StartTime is the process's start time, see File.StartTime.
+	StartTime uint64
+}
+
+// A FileStat contains the device and inode numbers of a File's pidfd, as
+// reported by fstat(2).
+type FileStat struct {
+	Dev, Ino uint64
+}
+
+// Stat returns the device and inode numbers of the File's pidfd. On Linux
+// 6.9+, which back pidfds with pidfs, they identify the process referred to by
+// the pidfd, and match those shown for the pidfd in /proc/<pid>/fd and by
+// tools such as lsof. On older kernels, every pidfd shares a single anonymous
+// inode.
+func (f *File) Stat() (FileStat, error) { return f.stat() }
+
+// OpenIdentified is like Open, but also returns the Identity of the process
+// at the time it was opened.
+func OpenIdentified(pid int) (*File, Identity, error) {
+	f, err := Open(pid)
+	if err != nil {
+		return nil, Identity{}, err
+	}
+
+	id, err := f.Identity()
+	if err != nil {
+		_ = f.Close()
+		return nil, Identity{}, err
+	}
+
+	return f, id, nil
+}
+
+// Identity returns the Identity of the process referred to by File.
+func (f *File) Identity() (Identity, error) { return f.identity() }
+
+// BootID returns the boot ID of the system on which the process referred to
+// by File is running, from /proc/sys/kernel/random/boot_id. A random boot ID
+// is generated each time the system boots.
+//
+// StartTime is measured relative to system boot, so a pid and start time
+// stored across a reboot may match an unrelated process. Callers which
+// persist process identities may store the boot ID alongside them and treat
+// a stored identity as stale if the boot ID has changed.
+func (f *File) BootID() (string, error) { return f.bootID() }
+
+// VerifyIdentity checks that the process referred to by File matches want,
+// typically an Identity returned by OpenIdentified for an earlier File. If the
+// process does not match, an *Error compatible with
+// errors.Is(err, ErrIdentityMismatch) is returned.
+func (f *File) VerifyIdentity(want Identity) error {
+	got, err := f.Identity()
+	if err != nil {
+		return err
+	}
+
+	if got != want {
+		return f.wrap(ErrIdentityMismatch)
+	}
+
+	return nil
+}
+
+// SendSignalIfIdentity sends sig to the process referred to by File only if
+// the process matches want, as reported by VerifyIdentity. If the process
+// does not match, no signal is sent and an *Error compatible with
+// errors.Is(err, ErrIdentityMismatch) is returned.
+//
+// A pidfd always refers to the same process, so a File opened by pidfd cannot
+// signal a process which reused its pid. SendSignalIfIdentity additionally
+// guards Files which were created from a pid or pidfd recorded elsewhere, such
+// as with NewFileConn, against referring to a different process than
+// intended. Because the pidfd cannot change, the check remains valid when the
+// signal is sent.
+func (f *File) SendSignalIfIdentity(sig os.Signal, want Identity) error {
+	if err := f.VerifyIdentity(want); err != nil {
+		return err
+	}
+
+	return f.SendSignal(sig)
+}
@@ -0,0 +1,87 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// pidfsMagic is the filesystem magic number of pidfs, from linux/magic.h.
+const pidfsMagic = 0x50494446
+
+// stat implements File.Stat.
+func (f *File) stat() (FileStat, error) {
+	var (
+		st  unix.Stat_t
+		err error
+	)
+	cerr := f.rc.Control(func(fd uintptr) {
+		err = unix.Fstat(int(fd), &st)
+	})
+	if cerr != nil {
+		return FileStat{}, f.wrap(cerr)
+	}
+	if err != nil {
+		return FileStat{}, f.wrap(os.NewSyscallError("fstat", err))
+	}
+
+	return FileStat{
+		Dev: uint64(st.Dev),
+		Ino: uint64(st.Ino),
+	}, nil
+}
+
+// identity implements File.Identity.
+func (f *File) identity() (Identity, error) {
+	st, err := f.stat()
+	if err != nil {
+		return Identity{}, err
+	}
+
+	var sfs unix.Statfs_t
+	cerr := f.rc.Control(func(fd uintptr) {
+		err = unix.Fstatfs(int(fd), &sfs)
+	})
+	if cerr != nil {
+		return Identity{}, f.wrap(cerr)
+	}
+	if err != nil {
+		return Identity{}, f.wrap(os.NewSyscallError("fstatfs", err))
+	}
+
+	start, err := f.startTime()
+	if err != nil {
+		return Identity{}, err
+	}
+
+	id := Identity{
+		PID:       f.pid,
+		StartTime: start,
+	}
+
+	// Before pidfs, every pidfd shared a single anonymous inode.
+	if sfs.Type == pidfsMagic {
+		id.Inode = st.Ino
+	}
+
+	return id, nil
+}
+
+// bootID implements File.BootID.
+func (f *File) bootID() (string, error) {
+	b, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return "", f.wrap(err)
+	}
+
+	// The boot ID is system-wide, but report a missing process consistently
+	// with other methods.
+	if err := f.exists(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
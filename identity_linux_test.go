@@ -0,0 +1,134 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestOpenIdentified(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+	_, other, _ := testSleepFile(t, 1*time.Hour)
+
+	f2, id, err := pidfd.OpenIdentified(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to open identified: %v", err)
+	}
+	defer f2.Close()
+
+	if id.PID != cmd.Process.Pid || id.StartTime == 0 {
+		t.Fatalf("unexpected Identity: %+v", id)
+	}
+
+	// Another File referring to the same process matches, but a File for a
+	// different process does not.
+	if err := f.VerifyIdentity(id); err != nil {
+		t.Fatalf("failed to verify identity: %v", err)
+	}
+	if err := other.VerifyIdentity(id); !errors.Is(err, pidfd.ErrIdentityMismatch) {
+		t.Fatalf("expected identity mismatch, but got: %v", err)
+	}
+
+	testReap(t, cmd)
+
+	if err := f.VerifyIdentity(id); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+	if _, _, err := pidfd.OpenIdentified(cmd.Process.Pid); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for open, but got: %v", err)
+	}
+}
+
+func TestFileStat(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	st, err := f.Stat()
+	if err != nil {
+		t.Fatalf("failed to stat pidfd: %v", err)
+	}
+
+	// The pidfd matches its entry in /proc/self/fd.
+	var want unix.Stat_t
+	path := fmt.Sprintf("/proc/self/fd/%d", f.EpollEvent().Fd)
+	if err := unix.Stat(path, &want); err != nil {
+		t.Fatalf("failed to stat %s: %v", path, err)
+	}
+
+	if st.Dev != uint64(want.Dev) || st.Ino != uint64(want.Ino) {
+		t.Fatalf("unexpected device and inode: want: %d/%d, got: %d/%d",
+			want.Dev, want.Ino, st.Dev, st.Ino)
+	}
+
+	testReap(t, cmd)
+
+	// The pidfd itself remains valid after the process exits.
+	if _, err := f.Stat(); err != nil {
+		t.Fatalf("failed to stat pidfd after exit: %v", err)
+	}
+}
+
+func TestFileBootID(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	id, err := f.BootID()
+	if err != nil {
+		t.Fatalf("failed to get boot ID: %v", err)
+	}
+
+	// The boot ID is a UUID, such as "7f5b2c4e-1e4a-4e3a-9d4c-8d6c2f0a1b2c".
+	if len(id) != 36 || strings.Count(id, "-") != 4 {
+		t.Fatalf("unexpected boot ID: %q", id)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.BootID(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileSendSignalIfIdentity(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+	_, other, _ := testSleepFile(t, 1*time.Hour)
+
+	id, err := f.Identity()
+	if err != nil {
+		t.Fatalf("failed to get identity: %v", err)
+	}
+
+	// The other process does not match, so it is not signaled.
+	if err := other.SendSignalIfIdentity(unix.SIGKILL, id); !errors.Is(err, pidfd.ErrIdentityMismatch) {
+		t.Fatalf("expected identity mismatch, but got: %v", err)
+	}
+	if wi, err := other.TryWait(); err != nil || wi != nil {
+		t.Fatalf("mismatched process must not be signaled: %+v, %v", wi, err)
+	}
+
+	if err := f.SendSignalIfIdentity(unix.SIGKILL, id); err != nil {
+		t.Fatalf("failed to signal matching process: %v", err)
+	}
+
+	wi, err := f.Peek(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+	if wi.Signal() != unix.SIGKILL {
+		t.Fatalf("unexpected WaitInfo: %+v", wi)
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package pidfd
+
+func (*File) stat() (FileStat, error)     { return FileStat{}, errUnimplemented }
+func (*File) identity() (Identity, error) { return Identity{}, errUnimplemented }
+func (*File) bootID() (string, error)     { return "", errUnimplemented }
@@ -0,0 +1,27 @@
+package pidfd
+
+// Info contains identifying information about a process. Process IDs are
+// reported from the perspective of the caller's pid namespace.
+type Info struct {
+	// Process, thread group, and parent process IDs.
+	PID, TGID, PPID int
+
+	// Real, effective, saved set, and filesystem user IDs.
+	RUID, EUID, SUID, FSUID int
+
+	// Real, effective, saved set, and filesystem group IDs.
+	RGID, EGID, SGID, FSGID int
+
+	// CgroupID is the ID of the process's cgroup v2 control group, or zero
+	// if it is unavailable.
+	CgroupID uint64
+}
+
+// Info returns identifying information about the process referred to by
+// File.
+//
+// On Linux 6.13+, the information is retrieved directly from the pidfd using
+// the PIDFD_GET_INFO ioctl, which is free of races and is always correct for
+// the process referred to by the pidfd. On older kernels, Info falls back to
+// parsing /proc/<pid>/status, and CgroupID is not populated.
+func (f *File) Info() (*Info, error) { return f.info() }
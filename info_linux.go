@@ -0,0 +1,131 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Constants and structures from include/uapi/linux/pidfd.h which are not
+// available in package unix.
+const (
+	// pidfdGetInfo is PIDFD_GET_INFO: _IOWR(PIDFS_IOCTL_MAGIC, 11, struct
+	// pidfd_info) using the generic ioctl encoding. Architectures with other
+	// encodings report ENOTTY and fall back to /proc.
+	pidfdGetInfo = 0xc040ff0b
+
+	pidfdInfoPID      = 1 << 0
+	pidfdInfoCreds    = 1 << 1
+	pidfdInfoCgroupID = 1 << 2
+)
+
+// pidfdInfo is struct pidfd_info, PIDFD_INFO_SIZE_VER0.
+type pidfdInfo struct {
+	Mask     uint64
+	CgroupID uint64
+	PID      uint32
+	TGID     uint32
+	PPID     uint32
+	RUID     uint32
+	RGID     uint32
+	EUID     uint32
+	EGID     uint32
+	SUID     uint32
+	SGID     uint32
+	FSUID    uint32
+	FSGID    uint32
+	_        uint32
+}
+
+// info implements File.Info.
+func (f *File) info() (*Info, error) {
+	pi, err := f.pidfdInfo(pidfdInfoPID | pidfdInfoCreds | pidfdInfoCgroupID)
+	switch {
+	case err == nil:
+		info := &Info{
+			PID:   int(pi.PID),
+			TGID:  int(pi.TGID),
+			PPID:  int(pi.PPID),
+			RUID:  int(pi.RUID),
+			EUID:  int(pi.EUID),
+			SUID:  int(pi.SUID),
+			FSUID: int(pi.FSUID),
+			RGID:  int(pi.RGID),
+			EGID:  int(pi.EGID),
+			SGID:  int(pi.SGID),
+			FSGID: int(pi.FSGID),
+		}
+		if pi.Mask&pidfdInfoCgroupID != 0 {
+			info.CgroupID = pi.CgroupID
+		}
+
+		return info, nil
+	case errors.Is(err, unix.ENOTTY), errors.Is(err, unix.EINVAL):
+		// PIDFD_GET_INFO is not supported, use /proc instead.
+		return f.procInfo()
+	default:
+		return nil, err
+	}
+}
+
+// pidfdInfo issues the PIDFD_GET_INFO ioctl for the fields in mask.
+func (f *File) pidfdInfo(mask uint64) (*pidfdInfo, error) {
+	pi := pidfdInfo{Mask: mask}
+
+	var errno unix.Errno
+	err := f.rc.Control(func(fd uintptr) {
+		_, _, errno = unix.Syscall(
+			unix.SYS_IOCTL,
+			fd,
+			pidfdGetInfo,
+			uintptr(unsafe.Pointer(&pi)),
+		)
+	})
+	if err != nil {
+		return nil, f.wrap(err)
+	}
+	if errno != 0 {
+		return nil, f.wrap(os.NewSyscallError("ioctl", errno))
+	}
+
+	return &pi, nil
+}
+
+// procInfo implements File.Info using /proc/<pid>/status.
+func (f *File) procInfo() (*Info, error) {
+	kvs, err := f.procKeyValues("status")
+	if err != nil {
+		return nil, err
+	}
+
+	var info Info
+	for _, v := range []struct {
+		key  string
+		dsts []*int
+	}{
+		{key: "Pid", dsts: []*int{&info.PID}},
+		{key: "Tgid", dsts: []*int{&info.TGID}},
+		{key: "PPid", dsts: []*int{&info.PPID}},
+		{key: "Uid", dsts: []*int{&info.RUID, &info.EUID, &info.SUID, &info.FSUID}},
+		{key: "Gid", dsts: []*int{&info.RGID, &info.EGID, &info.SGID, &info.FSGID}},
+	} {
+		fields := strings.Fields(kvs[v.key])
+		if len(fields) != len(v.dsts) {
+			return nil, f.wrap(errors.New("malformed status field " + strconv.Quote(v.key)))
+		}
+
+		for i, dst := range v.dsts {
+			if *dst, err = f.parseInt(fields[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &info, nil
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/mdlayher/pidfd"
+)
+
+func TestFileInfo(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	info, err := f.Info()
+	if err != nil {
+		t.Fatalf("failed to get info: %v", err)
+	}
+
+	var (
+		uid = os.Getuid()
+		gid = os.Getgid()
+	)
+
+	want := &pidfd.Info{
+		PID:   cmd.Process.Pid,
+		TGID:  cmd.Process.Pid,
+		PPID:  os.Getpid(),
+		RUID:  uid,
+		EUID:  uid,
+		SUID:  uid,
+		FSUID: uid,
+		RGID:  gid,
+		EGID:  gid,
+		SGID:  gid,
+		FSGID: gid,
+	}
+
+	// The cgroup ID depends on the kernel and system configuration.
+	if diff := cmp.Diff(want, info, cmpopts.IgnoreFields(pidfd.Info{}, "CgroupID")); diff != "" {
+		t.Fatalf("unexpected Info (-want +got):\n%s", diff)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.Info(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
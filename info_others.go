@@ -0,0 +1,5 @@
+//go:build !linux
+
+package pidfd
+
+func (*File) info() (*Info, error) { return nil, errUnimplemented }
@@ -0,0 +1,88 @@
+package pidfd
+
+import "encoding/json"
+
+var (
+	_ json.Marshaler = &Error{}
+	_ json.Marshaler = &WaitInfo{}
+)
+
+// MarshalJSON implements json.Marshaler. The underlying system call error
+// number, if any, is rendered by its symbolic name, such as "EPERM".
+func (e *Error) MarshalJSON() ([]byte, error) {
+	var msg, cause string
+	if e.Err != nil {
+		msg = e.Err.Error()
+	}
+	if e.PermissionCause != PermissionCauseUnknown {
+		cause = e.PermissionCause.String()
+	}
+
+	return json.Marshal(struct {
+		FD      int    `json:"fd"`
+		PID     int    `json:"pid"`
+		Error   string `json:"error"`
+		Errno   string `json:"errno,omitempty"`
+		TraceID string `json:"trace_id,omitempty"`
+		Cause   string `json:"permission_cause,omitempty"`
+	}{
+		FD:      e.FD,
+		PID:     e.PID,
+		Error:   msg,
+		Errno:   errnoName(innermost(e.Err)),
+		TraceID: e.TraceID,
+		Cause:   cause,
+	})
+}
+
+// MarshalJSON implements json.Marshaler. The si_code value is rendered by its
+// symbolic name, such as "CLD_EXITED", and any signal by its symbolic name,
+// such as "SIGTERM".
+func (wi *WaitInfo) MarshalJSON() ([]byte, error) {
+	var (
+		exitCode *int
+		signal   string
+	)
+
+	if wi.Exited() {
+		exitCode = &wi.Status
+	} else {
+		signal = signalName(wi.Status)
+	}
+
+	return json.Marshal(struct {
+		WaitedPID int    `json:"waited_pid"`
+		UID       int    `json:"uid"`
+		Code      string `json:"code"`
+		Status    int    `json:"status"`
+		ExitCode  *int   `json:"exit_code,omitempty"`
+		Signal    string `json:"signal,omitempty"`
+	}{
+		WaitedPID: wi.WaitedPID,
+		UID:       wi.UID,
+		Code:      codeName(wi.Code),
+		Status:    wi.Status,
+		ExitCode:  exitCode,
+		Signal:    signal,
+	})
+}
+
+// codeName returns the symbolic name of a SIGCHLD si_code value.
+func codeName(code int) string {
+	switch code {
+	case cldExited:
+		return "CLD_EXITED"
+	case cldKilled:
+		return "CLD_KILLED"
+	case cldDumped:
+		return "CLD_DUMPED"
+	case cldTrapped:
+		return "CLD_TRAPPED"
+	case cldStopped:
+		return "CLD_STOPPED"
+	case cldContinued:
+		return "CLD_CONTINUED"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,79 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		v    json.Marshaler
+		want string
+	}{
+		{
+			name: "Error",
+			v: &pidfd.Error{
+				FD:      3,
+				PID:     1,
+				Err:     os.NewSyscallError("pidfd_send_signal", unix.EPERM),
+				TraceID: "abc123",
+			},
+			want: `{"fd":3,"pid":1,"error":"pidfd_send_signal: operation not permitted","errno":"EPERM","trace_id":"abc123"}`,
+		},
+		{
+			name: "Error permission cause",
+			v: &pidfd.Error{
+				PID:             1,
+				Err:             unix.EPERM,
+				PermissionCause: pidfd.PermissionCausePtraceScope,
+			},
+			want: `{"fd":0,"pid":1,"error":"operation not permitted","errno":"EPERM","permission_cause":"restricted by kernel.yama.ptrace_scope"}`,
+		},
+		{
+			name: "WaitInfo exited",
+			v: &pidfd.WaitInfo{
+				WaitedPID: 100,
+				UID:       1000,
+				Code:      1,
+				Status:    0,
+			},
+			want: `{"waited_pid":100,"uid":1000,"code":"CLD_EXITED","status":0,"exit_code":0}`,
+		},
+		{
+			name: "WaitInfo killed",
+			v: &pidfd.WaitInfo{
+				WaitedPID: 100,
+				UID:       1000,
+				Code:      2,
+				Status:    int(unix.SIGTERM),
+			},
+			want: `{"waited_pid":100,"uid":1000,"code":"CLD_KILLED","status":15,"signal":"SIGTERM"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			b, err := json.Marshal(tt.v)
+			if err != nil {
+				t.Fatalf("failed to marshal JSON: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, string(b)); diff != "" {
+				t.Fatalf("unexpected JSON (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package pidfd
+
+import "sync"
+
+// kernel caches the result of KernelVersion.
+var kernel struct {
+	once                sync.Once
+	major, minor, patch int
+	err                 error
+}
+
+// KernelVersion returns the version of the running kernel, such as 6.1.0.
+// pidfd features vary by kernel version: for example, pidfd_open(2) requires
+// Linux 5.3+, and waitid(2) with P_PIDFD requires Linux 5.4+. KernelVersion
+// is intended for diagnostics, such as explaining why a feature is
+// unavailable; to decide whether a feature is available, callers should
+// prefer attempting to use it, such as by classifying an error from Open with
+// ClassifyOpenError.
+//
+// The version is parsed from the release reported by uname(2) once and
+// cached, because it cannot change while the calling process is running. A
+// missing patch level is reported as zero.
+func KernelVersion() (major, minor, patch int, err error) {
+	kernel.once.Do(func() {
+		kernel.major, kernel.minor, kernel.patch, kernel.err = kernelVersion()
+	})
+
+	return kernel.major, kernel.minor, kernel.patch, kernel.err
+}
@@ -0,0 +1,50 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// kernelVersion implements KernelVersion.
+func kernelVersion() (int, int, int, error) {
+	var u unix.Utsname
+	if err := unix.Uname(&u); err != nil {
+		return 0, 0, 0, os.NewSyscallError("uname", err)
+	}
+
+	return parseKernelVersion(unix.ByteSliceToString(u.Release[:]))
+}
+
+// parseKernelVersion parses a kernel release such as "6.1.0-13-amd64".
+func parseKernelVersion(release string) (int, int, int, error) {
+	// Trim any suffix following the numeric version.
+	s := release
+	if i := strings.IndexFunc(s, func(r rune) bool {
+		return r != '.' && (r < '0' || r > '9')
+	}); i != -1 {
+		s = s[:i]
+	}
+
+	fields := strings.Split(s, ".")
+	if len(fields) < 2 || len(fields) > 3 {
+		return 0, 0, 0, fmt.Errorf("pidfd: malformed kernel release %q", release)
+	}
+
+	var v [3]int
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("pidfd: malformed kernel release %q: %v", release, err)
+		}
+
+		v[i] = n
+	}
+
+	return v[0], v[1], v[2], nil
+}
@@ -0,0 +1,46 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestKernelVersion(t *testing.T) {
+	t.Parallel()
+
+	major, minor, patch, err := pidfd.KernelVersion()
+	if err != nil {
+		t.Fatalf("failed to get kernel version: %v", err)
+	}
+
+	// pidfd_open(2) requires Linux 5.3+, and every other test depends on it.
+	if major < 5 || (major == 5 && minor < 3) {
+		t.Fatalf("unexpected kernel version for pidfd support: %d.%d.%d", major, minor, patch)
+	}
+
+	var u unix.Utsname
+	if err := unix.Uname(&u); err != nil {
+		t.Fatalf("failed to get uname: %v", err)
+	}
+
+	// The release may omit a patch level of zero.
+	release := unix.ByteSliceToString(u.Release[:])
+	prefix := fmt.Sprintf("%d.%d", major, minor)
+	if patch != 0 {
+		prefix += fmt.Sprintf(".%d", patch)
+	}
+	if !strings.HasPrefix(release, prefix) {
+		t.Fatalf("kernel version %s does not match release %q", prefix, release)
+	}
+
+	// The version is cached.
+	if m2, n2, p2, err := pidfd.KernelVersion(); err != nil || m2 != major || n2 != minor || p2 != patch {
+		t.Fatalf("unexpected cached kernel version: %d.%d.%d, %v", m2, n2, p2, err)
+	}
+}
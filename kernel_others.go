@@ -0,0 +1,5 @@
+//go:build !linux
+
+package pidfd
+
+func kernelVersion() (int, int, int, error) { return 0, 0, 0, errUnimplemented }
@@ -0,0 +1,59 @@
+package pidfd
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// Metrics receives notifications about operations performed by all Files in
+// this package. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// IncOpen is called for each call to Open.
+	IncOpen()
+
+	// IncSignal is called for each call to File.SendSignal.
+	IncSignal(sig os.Signal)
+
+	// IncWait is called for each call to File.Wait.
+	IncWait()
+
+	// IncError is called when operation op ("open", "signal", or "wait")
+	// fails. errno is the system call error number (a unix.Errno on Linux)
+	// when one is available, or the operation's error otherwise.
+	IncError(op string, errno error)
+}
+
+// metrics stores the Metrics set by SetMetrics, or nil if none is set.
+var metrics atomic.Pointer[Metrics]
+
+// SetMetrics sets the Metrics implementation which is notified of operations
+// performed by all Files. Passing nil disables metrics, which is the default.
+func SetMetrics(m Metrics) {
+	if m == nil {
+		metrics.Store(nil)
+		return
+	}
+
+	metrics.Store(&m)
+}
+
+// loadMetrics returns the current Metrics, or nil if none is set.
+func loadMetrics() Metrics {
+	m := metrics.Load()
+	if m == nil {
+		return nil
+	}
+
+	return *m
+}
+
+// incError reports a non-nil err for op to m.
+func incError(m Metrics, op string, err error) {
+	if err == nil {
+		return
+	}
+
+	// Report the innermost errno if possible so implementations can use it
+	// as a low-cardinality label.
+	m.IncError(op, innermost(err))
+}
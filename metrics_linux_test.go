@@ -0,0 +1,107 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestSetMetrics(t *testing.T) {
+	// Not parallel: metrics are package-wide and would observe operations
+	// from other tests.
+	m := &testMetrics{}
+	pidfd.SetMetrics(m)
+	defer pidfd.SetMetrics(nil)
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+	if err := f.SendSignal(unix.SIGTERM); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for child process exit: %v", err)
+	}
+
+	if _, err := pidfd.Open(12345678); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+
+	want := metricsCounts{
+		Opens:   2,
+		Signals: []os.Signal{unix.SIGTERM},
+		Waits:   1,
+		Errors:  map[string]error{"open": unix.ESRCH},
+	}
+
+	if diff := cmp.Diff(want, m.c, cmp.Comparer(errors.Is)); diff != "" {
+		t.Fatalf("unexpected metrics (-want +got):\n%s", diff)
+	}
+}
+
+func TestFileWaitMetricsContextCanceled(t *testing.T) {
+	m := &testMetrics{}
+	pidfd.SetMetrics(m)
+	defer pidfd.SetMetrics(nil)
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := f.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled for wait, but got: %v", err)
+	}
+
+	if diff := cmp.Diff(context.Canceled, m.c.Errors["wait"], cmp.Comparer(errors.Is)); diff != "" {
+		t.Fatalf("unexpected wait error (-want +got):\n%s", diff)
+	}
+}
+
+var _ pidfd.Metrics = &testMetrics{}
+
+type testMetrics struct {
+	mu sync.Mutex
+	c  metricsCounts
+}
+
+type metricsCounts struct {
+	Opens   int
+	Signals []os.Signal
+	Waits   int
+	Errors  map[string]error
+}
+
+func (m *testMetrics) IncOpen() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.c.Opens++
+}
+
+func (m *testMetrics) IncSignal(sig os.Signal) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.c.Signals = append(m.c.Signals, sig)
+}
+
+func (m *testMetrics) IncWait() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.c.Waits++
+}
+
+func (m *testMetrics) IncError(op string, errno error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.c.Errors == nil {
+		m.c.Errors = make(map[string]error)
+	}
+	m.c.Errors[op] = errno
+}
@@ -0,0 +1,30 @@
+package pidfd
+
+import "errors"
+
+// ErrPIDNamespace is returned via errors.Is when a pid cannot be opened
+// because it belongs to a pid namespace which is not visible to the caller.
+var ErrPIDNamespace = errors.New("pidfd: pid is not visible in the caller's pid namespace")
+
+// OpenHostPID is like Open, but opens the process identified by hostPID in the
+// host's initial pid namespace, rather than in the caller's pid namespace.
+//
+// pidfd_open(2) always interprets pids in the caller's pid namespace, and the
+// processes of an ancestor namespace are not visible from a nested one, so a
+// host pid cannot be translated from inside a container. If the caller is not
+// in the initial pid namespace, OpenHostPID returns an *Error compatible with
+// errors.Is(err, ErrPIDNamespace) rather than opening an unrelated process
+// which happens to have the same pid in the caller's namespace. To open a
+// host process from a container, receive a pidfd from a process in the host's
+// pid namespace, such as with FileFromControlMessage.
+func OpenHostPID(hostPID int) (*File, error) {
+	ok, err := inInitPIDNamespace()
+	if err != nil {
+		return nil, &Error{PID: hostPID, Err: err}
+	}
+	if !ok {
+		return nil, &Error{PID: hostPID, Err: ErrPIDNamespace}
+	}
+
+	return Open(hostPID)
+}
@@ -0,0 +1,23 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"fmt"
+	"os"
+)
+
+// procPIDInitIno is the fixed inode number of the initial pid namespace, from
+// PROC_PID_INIT_INO in linux/proc_ns.h.
+const procPIDInitIno uint32 = 0xeffffffc
+
+// inInitPIDNamespace reports whether the calling process is a member of the
+// initial pid namespace.
+func inInitPIDNamespace() (bool, error) {
+	link, err := os.Readlink("/proc/self/ns/pid")
+	if err != nil {
+		return false, err
+	}
+
+	return link == fmt.Sprintf("pid:[%d]", procPIDInitIno), nil
+}
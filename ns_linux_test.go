@@ -0,0 +1,54 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/pidfd"
+)
+
+func TestOpenHostPID(t *testing.T) {
+	t.Parallel()
+
+	ctx, _, cmd := testSleepFile(t, 1*time.Hour)
+
+	f, err := pidfd.OpenHostPID(cmd.Process.Pid)
+	if !testInitPIDNamespace(t) {
+		if !errors.Is(err, pidfd.ErrPIDNamespace) {
+			t.Fatalf("expected pid namespace error, but got: %v", err)
+		}
+
+		return
+	}
+	if err != nil {
+		t.Fatalf("failed to open host pid: %v", err)
+	}
+	defer f.Close()
+
+	if err := f.SendSignal(os.Kill); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	if _, err := pidfd.OpenHostPID(12345678); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func testInitPIDNamespace(t *testing.T) bool {
+	t.Helper()
+
+	link, err := os.Readlink("/proc/self/ns/pid")
+	if err != nil {
+		t.Fatalf("failed to read pid namespace: %v", err)
+	}
+
+	return link == fmt.Sprintf("pid:[%d]", uint32(0xeffffffc))
+}
@@ -0,0 +1,5 @@
+//go:build !linux
+
+package pidfd
+
+func inInitPIDNamespace() (bool, error) { return false, errUnimplemented }
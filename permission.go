@@ -0,0 +1,36 @@
+package pidfd
+
+// A PermissionCause is a best-effort diagnosis of why an operation failed
+// with EPERM, to suggest a remedy.
+type PermissionCause int
+
+// Possible PermissionCause values.
+const (
+	// PermissionCauseUnknown indicates the cause was not diagnosed, either
+	// because the error is not EPERM or because no likely cause was found,
+	// such as when a seccomp filter or LSM policy denied the operation.
+	PermissionCauseUnknown PermissionCause = iota
+
+	// PermissionCauseCapability indicates the caller lacks CAP_SYS_PTRACE,
+	// which is required to access processes with different credentials.
+	PermissionCauseCapability
+
+	// PermissionCausePtraceScope indicates the operation is restricted by
+	// the Yama LSM's kernel.yama.ptrace_scope sysctl, see
+	// Documentation/admin-guide/LSM/Yama.rst.
+	PermissionCausePtraceScope
+)
+
+// String returns the string representation of a PermissionCause.
+func (pc PermissionCause) String() string {
+	switch pc {
+	case PermissionCauseUnknown:
+		return "unknown"
+	case PermissionCauseCapability:
+		return "missing CAP_SYS_PTRACE"
+	case PermissionCausePtraceScope:
+		return "restricted by kernel.yama.ptrace_scope"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,72 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Values of kernel.yama.ptrace_scope, from Documentation/admin-guide/LSM/Yama.rst.
+const (
+	ptraceScopeClassic    = 0
+	ptraceScopeRestricted = 1
+	ptraceScopeAdmin      = 2
+	ptraceScopeNoAttach   = 3
+)
+
+// permissionCause diagnoses the likely cause of an EPERM error from an
+// operation which is subject to ptrace(2) access checks. It is best-effort:
+// any failure to inspect the system results in PermissionCauseUnknown.
+func permissionCause() PermissionCause {
+	// A missing sysctl indicates that Yama is not enabled, which is
+	// equivalent to classic ptrace permissions.
+	scope := ptraceScopeClassic
+	b, err := os.ReadFile("/proc/sys/kernel/yama/ptrace_scope")
+	switch {
+	case err == nil:
+		if scope, err = strconv.Atoi(strings.TrimSpace(string(b))); err != nil {
+			return PermissionCauseUnknown
+		}
+	case !os.IsNotExist(err):
+		return PermissionCauseUnknown
+	}
+
+	hasCap, ok := callerHasCap(unix.CAP_SYS_PTRACE)
+	if !ok {
+		return PermissionCauseUnknown
+	}
+
+	switch {
+	case scope >= ptraceScopeNoAttach:
+		// No capability grants access.
+		return PermissionCausePtraceScope
+	case hasCap:
+		// The capability overrides the remaining checks, so something else
+		// denied the operation.
+		return PermissionCauseUnknown
+	case scope == ptraceScopeAdmin:
+		return PermissionCauseCapability
+	case scope == ptraceScopeRestricted:
+		// Only descendants are accessible without the capability.
+		return PermissionCausePtraceScope
+	default:
+		// Classic permissions: the target has different credentials.
+		return PermissionCauseCapability
+	}
+}
+
+// callerHasCap reports whether the calling thread has capability cap in its
+// effective set. ok is false if the capability sets cannot be retrieved.
+func callerHasCap(cap int) (has, ok bool) {
+	hdr := unix.CapUserHeader{Version: unix.LINUX_CAPABILITY_VERSION_3}
+	var data [2]unix.CapUserData
+	if err := unix.Capget(&hdr, &data[0]); err != nil {
+		return false, false
+	}
+
+	return data[cap/32].Effective&(1<<uint(cap%32)) != 0, true
+}
@@ -0,0 +1,5 @@
+//go:build !linux
+
+package pidfd
+
+func permissionCause() PermissionCause { return PermissionCauseUnknown }
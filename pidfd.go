@@ -5,37 +5,570 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 )
 
 // A File is a handle to a Linux pidfd. If the process referred to by the pidfd
 // no longer exists, File's methods will return an *Error value which is
-// compatible with errors.Is(err, os.ErrNotExist).
+// compatible with errors.Is(err, os.ErrNotExist). If the File has been closed,
+// File's methods will return an *Error value which is compatible with
+// errors.Is(err, os.ErrClosed).
 type File struct {
-	pid int
-	c   *conn
-	rc  syscall.RawConn
+	pid    int
+	c      *conn
+	rc     syscall.RawConn
+	closed atomic.Bool
+
+	// For Files opened by OpenSignalOnly, sig holds the pidfd until c is
+	// created on first use by upgrade.
+	sig        *os.File
+	upgrade    sync.Once
+	upgradeErr error
+
+	// mu protects cancelation functions for in-flight waits, which are
+	// tracked by waits, and for background goroutines, which are tracked by
+	// bg.
+	mu      sync.Mutex
+	waitID  uint64
+	cancels map[uint64]context.CancelFunc
+	waits   sync.WaitGroup
+	bgCtx   context.Context
+	bgStop  context.CancelFunc
+	bg      sync.WaitGroup
 }
 
 // Open opens a pidfd File referring to the process identified by pid. If the
 // process does not exist, an *Error value is returned which is compatible with
 // errors.Is(err, os.ErrNotExist).
-func Open(pid int) (*File, error) { return open(pid) }
+func Open(pid int, opts ...OpenOption) (*File, error) {
+	var o openOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	f, err := open(pid)
+	if err == nil && o.inheritable {
+		if err = f.setInheritable(); err != nil {
+			_ = f.Close()
+			f = nil
+		}
+	}
+	if m := loadMetrics(); m != nil {
+		m.IncOpen()
+		incError(m, "open", err)
+	}
+	logEvent(context.Background(), "open", pid, f, nil, err)
+
+	return f, err
+}
+
+// NewFileConn creates a File from c, a Conn which wraps a pidfd referring to
+// the process identified by pid. This allows callers to manage pidfds using
+// their own socket.Conn infrastructure on Linux.
+//
+// The File takes ownership of c: File.Close closes c, and the caller must not
+// close c or set deadlines on c while the File is in use, because File uses
+// the read deadline of c to implement cancelation for Wait.
+func NewFileConn(c *Conn, pid int) (*File, error) { return newFileConn(c, pid) }
+
+// OpenWithDone is like Open, but also returns a channel which is closed when
+// the process exits, for use in select statements. The process need not be a
+// child of the caller. The exit is observed by a goroutine which is stopped
+// when the File is closed; if the File is closed before the process exits,
+// the channel is never closed.
+func OpenWithDone(pid int) (*File, <-chan struct{}, error) {
+	f, err := Open(pid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	done := make(chan struct{})
+	f.goBackground(func(ctx context.Context) {
+		if err := f.waitReadable(ctx); err == nil {
+			close(done)
+		}
+	})
+
+	return f, done, nil
+}
+
+// Bounds for the exponential backoff between attempts by OpenUntil and
+// OpenTraced, which share a retry loop.
+const (
+	openRetryMinDelay = 1 * time.Millisecond
+	openRetryMaxDelay = 100 * time.Millisecond
+)
+
+// OpenUntil is like Open, but if the process does not exist, it retries with
+// exponential backoff until the process exists or deadline passes. It is
+// intended for supervisors which learn of a pid before its process is visible,
+// such as from a fork in another program, and which cannot use Start to avoid
+// the race. Any error other than the process not existing is returned
+// immediately.
+//
+// If deadline passes before the process exists, OpenUntil returns the error
+// from the final attempt, which is compatible with errors.Is(err,
+// os.ErrNotExist).
+func OpenUntil(deadline time.Time, pid int) (*File, error) {
+	f, _, err := OpenTraced(deadline, pid)
+	return f, err
+}
+
+// An OpenTrace reports how OpenTraced resolved a race between a process
+// starting and its pidfd being opened.
+type OpenTrace struct {
+	// Retries is the number of attempts which failed because the process did
+	// not exist yet.
+	Retries int
+
+	// Elapsed is the total time spent opening the pidfd, including any
+	// retries.
+	Elapsed time.Duration
+}
+
+// OpenTraced is like OpenUntil, but also returns an OpenTrace describing how
+// often the process did not exist yet and how long opening took, for
+// supervisors which want visibility into how often the race window is hit.
+// If the process exists on the first attempt, OpenTraced adds little overhead
+// beyond Open. The OpenTrace is also returned if an error occurs.
+func OpenTraced(deadline time.Time, pid int) (*File, OpenTrace, error) {
+	var (
+		start = time.Now()
+		delay = openRetryMinDelay
+		trace OpenTrace
+	)
+
+	for {
+		f, err := Open(pid)
+		if err == nil || !errors.Is(err, os.ErrNotExist) {
+			trace.Elapsed = time.Since(start)
+			return f, trace, err
+		}
+
+		left := time.Until(deadline)
+		if left <= 0 {
+			trace.Elapsed = time.Since(start)
+			return nil, trace, err
+		}
+		if delay > left {
+			delay = left
+		}
+
+		trace.Retries++
+		time.Sleep(delay)
+		if delay *= 2; delay > openRetryMaxDelay {
+			delay = openRetryMaxDelay
+		}
+	}
+}
+
+// An OpenOption configures Open.
+type OpenOption func(*openOptions)
+
+// openOptions contains the configuration set by OpenOptions.
+type openOptions struct {
+	inheritable bool
+}
+
+// WithInheritable configures Open to clear the close-on-exec flag of the
+// File's pidfd, so that the pidfd is inherited by programs executed by the
+// calling process, such as with syscall.Exec. This is not necessary to pass a
+// File to a child started with exec.Cmd.ExtraFiles.
+//
+// An inheritable pidfd is inherited by every program executed by any thread
+// of the calling process, and allows each of them to signal the process
+// referred to by the pidfd for as long as they hold it. Only use
+// WithInheritable when the executed program is trusted with that capability.
+func WithInheritable() OpenOption {
+	return func(o *openOptions) { o.inheritable = true }
+}
+
+// OpenProcess opens a pidfd File referring to process p, such as the Process
+// of a started exec.Cmd.
+//
+// The pidfd is opened by pid, so it only refers to p if p's pid has not been
+// reused by another process. A child process's pid cannot be reused until the
+// child is reaped, so OpenProcess is safe to call for a child process any time
+// before p.Wait or exec.Cmd.Wait is called. To avoid this race entirely, see
+// Start.
+func OpenProcess(p *os.Process) (*File, error) { return Open(p.Pid) }
+
+// OpenSelf opens a pidfd File referring to the calling process. It is
+// typically used to hand a pidfd to a watchdog, such as a sibling process
+// started with the File in exec.Cmd.ExtraFiles, so that the watchdog learns
+// of the calling process's exit promptly. A process which is not the parent of
+// the calling process cannot use Wait, which requires a child process, but it
+// can observe the exit with a Group or Waiter.
+//
+// Signals sent with SendSignal are delivered to the calling process as with
+// kill(2).
+func OpenSelf() (*File, error) { return Open(os.Getpid()) }
+
+// OpenSignalOnly is like Open, but opens a lighter File intended for callers
+// which only send signals, such as tools which manage many processes. The
+// pidfd is not registered with the Go runtime network poller until a method
+// which must wait for the process, such as Wait, is first called, at which
+// point the File is transparently upgraded to a full File.
+func OpenSignalOnly(pid int) (*File, error) {
+	f, err := openSignalOnly(pid)
+	if m := loadMetrics(); m != nil {
+		m.IncOpen()
+		incError(m, "open", err)
+	}
+	logEvent(context.Background(), "open", pid, f, nil, err)
+
+	return f, err
+}
 
 // Close releases the File's resources.
-func (f *File) Close() error { return f.c.Close() }
+//
+// Close unblocks any in-flight waits on File, such as Wait, and waits for them
+// to return before releasing the pidfd. If the process exited before an
+// in-flight wait observed Close, the wait reports the exit; otherwise it
+// returns an *Error compatible with errors.Is(err, os.ErrClosed).
+func (f *File) Close() error {
+	// The File is no longer leaked, see SetFinalizerWarnings.
+	runtime.SetFinalizer(f, nil)
+	f.closed.Store(true)
+	f.stopWaits()
+	f.stopBackground()
+	return f.close()
+}
+
+// goBackground runs fn in a goroutine which is stopped by canceling ctx and
+// waited for when File is closed. If File is already closed, fn is not run.
+func (f *File) goBackground(fn func(ctx context.Context)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed.Load() {
+		return
+	}
+	if f.bgStop == nil {
+		ctx := context.WithValue(context.Background(), backgroundKey{}, true)
+		f.bgCtx, f.bgStop = context.WithCancel(ctx)
+	}
+
+	f.bg.Add(1)
+	go func(ctx context.Context) {
+		defer f.bg.Done()
+		fn(ctx)
+	}(f.bgCtx)
+}
+
+// backgroundKey is a context key which marks the context of goroutines started
+// by goBackground. Their waits are internal to File, so CancelWait does not
+// cancel them.
+type backgroundKey struct{}
+
+// isBackground reports whether ctx belongs to a goroutine started by
+// goBackground.
+func isBackground(ctx context.Context) bool {
+	return ctx.Value(backgroundKey{}) != nil
+}
+
+// stopBackground stops and waits for all goroutines started by goBackground.
+func (f *File) stopBackground() {
+	f.mu.Lock()
+	if f.bgStop != nil {
+		f.bgStop()
+	}
+	f.mu.Unlock()
+
+	f.bg.Wait()
+}
+
+// checkOpen returns an *Error compatible with errors.Is(err, os.ErrClosed) if
+// File has been closed, so that callers are not exposed to the various errors
+// produced by operations on a closed file descriptor.
+func (f *File) checkOpen() error {
+	if !f.closed.Load() {
+		return nil
+	}
+
+	return &Error{PID: f.pid, FD: -1, Err: os.ErrClosed}
+}
+
+// CloseAll closes each of files, even if closing any of them fails. The
+// returned error joins the errors from every failed Close. Nil Files are
+// skipped.
+func CloseAll(files ...*File) error {
+	errs := make([]error, 0, len(files))
+	for _, f := range files {
+		if f == nil {
+			continue
+		}
+
+		errs = append(errs, f.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// An EpollEvent describes the File's pidfd for registration in an epoll(7)
+// instance. Its fields match those of unix.EpollEvent on Linux.
+type EpollEvent struct {
+	Events uint32
+	Fd     int32
+}
+
+// EpollEvent returns an event for registering the File's pidfd in a
+// caller-managed epoll(7) instance using EPOLL_CTL_ADD. This is intended for
+// integration with existing event loops; most callers should use Wait or
+// Group instead.
+//
+// A pidfd becomes readable (EPOLLIN) when its process exits and remains
+// readable from then on, so with EPOLLET added to Events, the event is
+// reported exactly once. Fd refers to the File's current file descriptor,
+// which is only valid until the File is closed; the pidfd must be removed
+// from the epoll instance before closing the File. If the File is already
+// closed, Fd is -1.
+func (f *File) EpollEvent() EpollEvent { return f.epollEvent() }
 
 // SendSignal sends a signal the process referred to by File. Note that
 // unix.Signal values also implement os.Signal.
 func (f *File) SendSignal(signal os.Signal) error {
 	// TODO(mdlayher): expose info parameter?
-	return f.sendSignal(signal)
+	err := f.sendSignal(signal)
+	if m := loadMetrics(); m != nil {
+		m.IncSignal(signal)
+		incError(m, "signal", err)
+	}
+	logEvent(context.Background(), "signal", f.pid, f, signal, err)
+
+	return err
+}
+
+// SendSignalAllThreads sends signal to each thread of the process referred to
+// by File individually, rather than to the process as a whole as with
+// SendSignal, such as for runtimes which implement cooperative thread control.
+// Threads are enumerated from /proc/<pid>/task and signaled using a thread
+// pidfd opened with PIDFD_THREAD on Linux 6.9+, or tgkill(2) on older
+// kernels. Errors for individual threads are joined.
+//
+// Threads may start and exit at any time, so threads which start during
+// enumeration may not be signaled, and threads which exit before they are
+// signaled are skipped. The pidfd is used to check that the process still
+// exists beforehand, which narrows but cannot eliminate the window for
+// signaling a process which reused the pid.
+func (f *File) SendSignalAllThreads(signal os.Signal) error {
+	return f.sendSignalAllThreads(signal)
+}
+
+// CanSignal reports whether the caller has permission to send signals to the
+// process referred to by File, without sending a signal. If permission is
+// denied, CanSignal returns false and an *Error compatible with
+// errors.Is(err, os.ErrPermission). If the process no longer exists, the
+// *Error is compatible with errors.Is(err, os.ErrNotExist).
+func (f *File) CanSignal() (bool, error) {
+	if err := f.signalZero(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// IsStillPermitted reports whether the caller is still permitted to send
+// signals to the process referred to by File, such as after the caller drops
+// privileges. Unlike CanSignal, a lack of permission is reported as false
+// with a nil error; other errors, such as when the process no longer exists,
+// are returned as with CanSignal.
+//
+// A File remains open and usable for waiting after the caller drops
+// privileges: the kernel only checks permissions for waits when the pidfd is
+// opened. Signal permission, however, is checked against the caller's
+// credentials each time a signal is sent, so a caller which opened a File
+// while privileged may no longer be permitted to signal the process after
+// dropping privileges.
+func (f *File) IsStillPermitted() (bool, error) {
+	ok, err := f.CanSignal()
+	if errors.Is(err, os.ErrPermission) {
+		return false, nil
+	}
+
+	return ok, err
 }
 
 // Wait waits for the process referred to by File to exit. If the context is
 // canceled, Wait will unblock and return an error.
+//
+// Wait implements cancelation using the read deadline of the File's pidfd,
+// which is always cleared before Wait returns. The pidfd is owned by File, so
+// no other read deadline may be set on it; see NewFileConn.
 func (f *File) Wait(ctx context.Context) error {
-	return f.wait(ctx)
+	err := f.traceError(ctx, f.wait(ctx))
+	if m := loadMetrics(); m != nil {
+		m.IncWait()
+		incError(m, "wait", err)
+	}
+	logEvent(ctx, "wait", f.pid, f, nil, err)
+
+	return err
+}
+
+// CancelWait unblocks any in-flight waits on File, such as Wait, which will
+// return an error compatible with errors.Is(err, context.Canceled). It is an
+// alternative to context cancelation for callers which use other cancelation
+// mechanisms. If no wait is in progress, CancelWait is a no-op.
+//
+// Waits started internally by File, such as the reap started by
+// SignalAndReapAsync, are not affected by CancelWait; they are stopped only by
+// Close.
+func (f *File) CancelWait() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, cancel := range f.cancels {
+		cancel()
+	}
+
+	return nil
+}
+
+// trackWait registers the cancelation function of an in-flight wait for use
+// by CancelWait and Close. The returned function must be called when the wait
+// is done. If File is already closed, the wait is canceled immediately.
+func (f *File) trackWait(cancel context.CancelFunc) (done func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed.Load() {
+		cancel()
+		return func() {}
+	}
+	if f.cancels == nil {
+		f.cancels = make(map[uint64]context.CancelFunc)
+	}
+
+	id := f.waitID
+	f.waitID++
+	f.cancels[id] = cancel
+	f.waits.Add(1)
+
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		delete(f.cancels, id)
+		f.waits.Done()
+	}
+}
+
+// stopWaits cancels and waits for all in-flight waits registered by
+// trackWait. File must already be marked closed so that no more waits are
+// registered.
+func (f *File) stopWaits() {
+	f.mu.Lock()
+	for _, cancel := range f.cancels {
+		cancel()
+	}
+	f.mu.Unlock()
+
+	f.waits.Wait()
+}
+
+// WaitUntilSignal waits for the process referred to by File to exit, or for
+// the calling process to receive one of sigs, whichever happens first. This is
+// useful for servers which must respond to their own shutdown signals while
+// waiting for a child process.
+//
+// If the process exited, exited is true. If one of sigs was received, it is
+// returned as received. Both may be set if the process exited at the same
+// time the signal was received. If sigs is empty, WaitUntilSignal is
+// equivalent to Wait.
+//
+// If the context is canceled, WaitUntilSignal will unblock and return an
+// error.
+func (f *File) WaitUntilSignal(ctx context.Context, sigs ...os.Signal) (exited bool, received os.Signal, err error) {
+	if len(sigs) == 0 {
+		// signal.Notify would relay all incoming signals.
+		if err := f.Wait(ctx); err != nil {
+			return false, nil, err
+		}
+
+		return true, nil, nil
+	}
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, sigs...)
+	defer signal.Stop(sigC)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errC := make(chan error, 1)
+	go func() { errC <- f.Wait(ctx) }()
+
+	select {
+	case err := <-errC:
+		if err != nil {
+			return false, nil, err
+		}
+
+		return true, nil, nil
+	case sig := <-sigC:
+		// Stop waiting, but check whether the process exited concurrently.
+		cancel()
+		switch err := <-errC; {
+		case err == nil:
+			return true, sig, nil
+		case errors.Is(err, context.Canceled):
+			return false, sig, nil
+		default:
+			return false, sig, err
+		}
+	}
+}
+
+// ErrFDExhausted is returned via errors.Is when an operation fails because the
+// calling process or the system has run out of file descriptors.
+var ErrFDExhausted = errors.New("pidfd: file descriptors exhausted")
+
+// ErrBadDescriptor is returned via errors.Is when an operation fails because
+// the File's pidfd is no longer a valid file descriptor, such as when another
+// part of the program closed it out from under the File. It indicates a bug
+// in the program rather than a change in the process's state.
+var ErrBadDescriptor = errors.New("pidfd: bad file descriptor")
+
+// traceIDKey is the context key for WithTraceID.
+type traceIDKey struct{}
+
+// WithTraceID returns a copy of ctx carrying correlation ID id. Errors from
+// File methods which accept a context, such as Wait, are returned as *Error
+// values with TraceID set to id, so that they can be correlated with logs
+// from other subsystems.
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, id)
+}
+
+// traceError annotates a non-nil err with the trace ID from ctx, if any.
+func (f *File) traceError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	id, ok := ctx.Value(traceIDKey{}).(string)
+	if !ok {
+		return err
+	}
+
+	if e, ok := err.(*Error); ok {
+		// Don't modify an existing *Error which may be shared.
+		cp := *e
+		cp.TraceID = id
+		return &cp
+	}
+
+	if e, ok := f.wrap(err).(*Error); ok {
+		e.TraceID = id
+		return e
+	}
+
+	return err
 }
 
 // Ensure compatibility with package errors.
@@ -51,11 +584,27 @@ var _ interface {
 type Error struct {
 	FD, PID int
 	Err     error
+
+	// TraceID is the correlation ID set on the context of the operation by
+	// WithTraceID, if any.
+	TraceID string
+
+	// PermissionCause is a best-effort diagnosis of why Open failed with
+	// EPERM. It is PermissionCauseUnknown for all other errors.
+	PermissionCause PermissionCause
 }
 
 // Error implements error.
 func (e *Error) Error() string {
-	return fmt.Sprintf("pidfd %d: pid: %d: %v", e.FD, e.PID, e.Err)
+	msg := fmt.Sprintf("pidfd %d: pid: %d: %v", e.FD, e.PID, e.Err)
+	if e.TraceID != "" {
+		msg = fmt.Sprintf("pidfd %d: pid: %d: trace %s: %v", e.FD, e.PID, e.TraceID, e.Err)
+	}
+	if e.PermissionCause != PermissionCauseUnknown {
+		msg += " (" + e.PermissionCause.String() + ")"
+	}
+
+	return msg
 }
 
 // Is implements errors.Is comparison.
@@ -64,6 +613,12 @@ func (e *Error) Is(target error) bool {
 	case os.ErrNotExist:
 		// No such process.
 		return errors.Is(e.Err, esrch)
+	case ErrFDExhausted:
+		// Per-process or system-wide file descriptor limit reached.
+		return errors.Is(e.Err, emfile) || errors.Is(e.Err, enfile)
+	case ErrBadDescriptor:
+		// The pidfd was closed without closing the File.
+		return errors.Is(e.Err, ebadf)
 	default:
 		// Fall back to the next error in the chain.
 		return false
@@ -72,3 +627,29 @@ func (e *Error) Is(target error) bool {
 
 // Unwrap implements errors.Unwrap functionality.
 func (e *Error) Unwrap() error { return e.Err }
+
+// Equal reports whether e and other have the same PID, FD, and underlying
+// error. Errors are compared after unwrapping them fully, so the same errno
+// is considered equal regardless of how it was wrapped. Equal is also used by
+// github.com/google/go-cmp for comparisons.
+func (e *Error) Equal(other *Error) bool {
+	if e == nil || other == nil {
+		return e == other
+	}
+
+	return e.PID == other.PID &&
+		e.FD == other.FD &&
+		errors.Is(innermost(e.Err), innermost(other.Err))
+}
+
+// innermost returns the innermost error in err's chain, such as a system call
+// errno.
+func innermost(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
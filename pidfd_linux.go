@@ -4,22 +4,53 @@ package pidfd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"sync"
+	"strconv"
 	"time"
 
 	"github.com/mdlayher/socket"
 	"golang.org/x/sys/unix"
 )
 
-// esrch is the "no such process" errno.
-var esrch = unix.ESRCH
+// Errno values used for Error.Is comparisons.
+var (
+	// esrch is the "no such process" errno.
+	esrch = unix.ESRCH
+
+	// emfile and enfile are the per-process and system-wide "too many open
+	// files" errnos.
+	emfile = unix.EMFILE
+	enfile = unix.ENFILE
+
+	// ebadf is the "bad file descriptor" errno.
+	ebadf = unix.EBADF
+
+	// enosys indicates the kernel does not support a system call.
+	enosys = unix.ENOSYS
+)
+
+// errnoName returns the symbolic name of err if it is an errno, or the empty
+// string otherwise.
+func errnoName(err error) string {
+	errno, ok := err.(unix.Errno)
+	if !ok {
+		return ""
+	}
+
+	return unix.ErrnoName(errno)
+}
 
 // A conn backs File for Linux pidfds. We can use socket.Conn directly on Linux
 // to implement most of the necessary methods.
 type conn = socket.Conn
 
+// A Conn wraps a pidfd for use with NewFileConn. On Linux, Conn is
+// socket.Conn, which allows callers to manage pidfds using their own
+// socket.Conn infrastructure.
+type Conn = socket.Conn
+
 // open opens a pidfd File.
 func open(pid int) (*File, error) {
 	// Open nonblocking: we always use asynchronous I/O anyway with
@@ -30,24 +61,164 @@ func open(pid int) (*File, error) {
 	fd, err := unix.PidfdOpen(pid, unix.PIDFD_NONBLOCK)
 	if err != nil {
 		// No FD to annotate the error yet.
-		return nil, &Error{PID: pid, Err: err}
+		return nil, openError(pid, err)
+	}
+
+	return newFile(fd, pid)
+}
+
+// openSignalOnly opens a pidfd File without a conn, see OpenSignalOnly.
+func openSignalOnly(pid int) (*File, error) {
+	// Open blocking so that os.NewFile does not register the pidfd with the
+	// runtime poller. The pidfd is only used via RawConn.Control until it is
+	// upgraded.
+	fd, err := unix.PidfdOpen(pid, 0)
+	if err != nil {
+		// No FD to annotate the error yet.
+		return nil, openError(pid, err)
+	}
+
+	sig := os.NewFile(uintptr(fd), "pidfd")
+	rc, err := sig.SyscallConn()
+	if err != nil {
+		_ = sig.Close()
+		return nil, err
+	}
+
+	f := &File{
+		pid: pid,
+		rc:  rc,
+		sig: sig,
 	}
+	setFinalizer(f)
 
+	return f, nil
+}
+
+// openError creates an *Error for a pidfd_open(2) failure for pid.
+func openError(pid int, err error) error {
+	e := &Error{PID: pid, Err: err}
+	if err == unix.EPERM {
+		// pidfd_open(2) itself performs no permission checks, but sandboxes
+		// and security modules may deny it.
+		e.PermissionCause = permissionCause()
+	}
+
+	return e
+}
+
+// newFile creates a File which takes ownership of pidfd fd referring to pid.
+func newFile(fd, pid int) (*File, error) {
 	c, err := socket.New(fd, "pidfd")
 	if err != nil {
+		// socket.New does not take ownership of fd on failure.
+		_ = unix.Close(fd)
+		return nil, err
+	}
+
+	f, err := newFileConn(c, pid)
+	if err != nil {
+		_ = c.Close()
 		return nil, err
 	}
 
+	return f, nil
+}
+
+// newFileConn implements NewFileConn.
+func newFileConn(c *Conn, pid int) (*File, error) {
 	rc, err := c.SyscallConn()
 	if err != nil {
 		return nil, err
 	}
 
-	return &File{
+	f := &File{
 		pid: pid,
 		c:   c,
 		rc:  rc,
-	}, nil
+	}
+	setFinalizer(f)
+
+	return f, nil
+}
+
+// setInheritable clears the close-on-exec flag of the pidfd.
+func (f *File) setInheritable() error {
+	var err error
+	cerr := f.rc.Control(func(fd uintptr) {
+		_, err = unix.FcntlInt(fd, unix.F_SETFD, 0)
+	})
+	if cerr != nil {
+		return f.wrap(cerr)
+	}
+
+	return f.wrap(os.NewSyscallError("fcntl", err))
+}
+
+// conn returns the File's conn, upgrading a File opened by OpenSignalOnly if
+// necessary.
+func (f *File) conn() (*conn, error) {
+	f.upgrade.Do(f.upgradeConn)
+	if f.c == nil {
+		if err := f.checkOpen(); err != nil {
+			return nil, err
+		}
+
+		return nil, f.upgradeErr
+	}
+
+	return f.c, nil
+}
+
+// upgradeConn creates the conn for a File opened by OpenSignalOnly. It must
+// only be called via f.upgrade.
+func (f *File) upgradeConn() {
+	if f.c != nil || f.closed.Load() {
+		return
+	}
+
+	// The pidfd is owned by f.sig, so the conn uses a duplicate which shares
+	// the same open file description.
+	var (
+		fd  int
+		err error
+	)
+	cerr := f.rc.Control(func(cfd uintptr) {
+		fd, err = unix.FcntlInt(cfd, unix.F_DUPFD_CLOEXEC, 0)
+	})
+	if cerr != nil {
+		f.upgradeErr = f.wrap(cerr)
+		return
+	}
+	if err != nil {
+		f.upgradeErr = f.wrap(os.NewSyscallError("fcntl", err))
+		return
+	}
+
+	c, err := socket.New(fd, "pidfd")
+	if err != nil {
+		f.upgradeErr = f.wrap(err)
+		return
+	}
+
+	f.c = c
+}
+
+// close implements File.Close.
+func (f *File) close() error {
+	// Wait for any in-progress upgrade so that its conn is also closed, and
+	// prevent any further upgrade.
+	f.upgrade.Do(func() {})
+
+	var errs []error
+	if f.c != nil {
+		errs = append(errs, f.c.Close())
+	}
+	if f.sig != nil {
+		errs = append(errs, f.sig.Close())
+	}
+
+	return errors.Join(errs...)
 }
 
 // sendSignal signals the process referred to by File.
@@ -65,36 +236,246 @@ func (f *File) sendSignal(signal os.Signal) error {
 	//
 	// "The flags argument is reserved for future use; currently, this argument
 	// must be specified as 0."
-	return f.wrap(f.c.PidfdSendSignal(ssig, nil, 0))
+	return f.pidfdSendSignal(ssig)
 }
 
+// pidfdThread is PIDFD_THREAD, which is not available in package unix.
+const pidfdThread = unix.O_EXCL
+
+// sendSignalAllThreads implements File.SendSignalAllThreads.
+func (f *File) sendSignalAllThreads(signal os.Signal) error {
+	ssig, ok := signal.(unix.Signal)
+	if !ok {
+		return fmt.Errorf("pidfd: invalid signal type for File.SendSignalAllThreads: %T", signal)
+	}
+
+	if err := f.exists(); err != nil {
+		return err
+	}
+
+	des, err := os.ReadDir(f.procPath("task"))
+	if err != nil {
+		return f.wrap(err)
+	}
+
+	var errs []error
+	for _, de := range des {
+		tid, err := strconv.Atoi(de.Name())
+		if err != nil {
+			continue
+		}
+
+		if err := signalThread(f.pid, tid, ssig); err != nil && !errors.Is(err, unix.ESRCH) {
+			// Threads which exited during enumeration are skipped.
+			errs = append(errs, err)
+		}
+	}
+
+	return f.wrap(errors.Join(errs...))
+}
+
+// signalThread sends sig to thread tid of the process tgid.
+func signalThread(tgid, tid int, sig unix.Signal) error {
+	fd, err := unix.PidfdOpen(tid, pidfdThread)
+	switch {
+	case err == nil:
+		defer unix.Close(fd)
+
+		// A thread pidfd signals only its thread.
+		return os.NewSyscallError("pidfd_send_signal", unix.PidfdSendSignal(fd, sig, nil, 0))
+	case errors.Is(err, unix.EINVAL):
+		// PIDFD_THREAD is not supported before Linux 6.9.
+		return os.NewSyscallError("tgkill", unix.Tgkill(tgid, tid, sig))
+	default:
+		return os.NewSyscallError("pidfd_open", err)
+	}
+}
+
+// epollEvent implements File.EpollEvent.
+func (f *File) epollEvent() EpollEvent {
+	fd := -1
+	_ = f.rc.Control(func(cfd uintptr) {
+		fd = int(cfd)
+	})
+
+	return EpollEvent{
+		Events: unix.EPOLLIN,
+		Fd:     int32(fd),
+	}
+}
+
+// signalZero checks for the existence of and permission to signal the
+// process referred to by File.
+func (f *File) signalZero() error {
+	// From pidfd_send_signal(2): "If sig is 0, then no signal is sent, but
+	// error checking is still performed."
+	return f.pidfdSendSignal(0)
+}
+
+// pidfdSendSignal performs pidfd_send_signal(2) for sig. Signals are sent
+// using RawConn.Control rather than the conn so that Files opened by
+// OpenSignalOnly are not upgraded.
+func (f *File) pidfdSendSignal(sig unix.Signal) error {
+	var err error
+	cerr := f.rc.Control(func(fd uintptr) {
+		err = unix.PidfdSendSignal(int(fd), sig, nil, 0)
+	})
+	if cerr != nil {
+		return f.wrap(cerr)
+	}
+
+	return f.wrap(os.NewSyscallError("pidfd_send_signal", err))
+}
+
+// wait waits for the process referred to by File to exit.
 func (f *File) wait(ctx context.Context) error {
-	// To observe context cancelation, we will set a past deadline in a
-	// goroutine to force blocked Reads to unblock.
+	_, err := f.waitid(ctx, unix.WEXITED|unix.WNOWAIT, nil)
+	return err
+}
+
+// waitid waits for a state change specified by options in the process referred
+// to by File, returning the siginfo reported by the kernel. If ru is not nil,
+// it receives the resource usage of the process.
+func (f *File) waitid(ctx context.Context, options int, ru *unix.Rusage) (*unix.Siginfo, error) {
+	c, err := f.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	// CancelWait may also cancel the wait, unless it is internal to File.
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	if !isBackground(ctx) {
+		defer f.trackWait(cancel)()
+	}
 
-	var wg sync.WaitGroup
-	wg.Add(1)
-	defer wg.Wait()
+	var si unix.Siginfo
+	err = readContext(ctx, c, func() error {
+		return c.Waitid(unix.P_PIDFD, &si, options, ru)
+	})
+	if err != nil {
+		// The File may have been closed while waiting.
+		if cerr := f.checkOpen(); cerr != nil {
+			// Close waits for this wait to return before releasing the pidfd,
+			// so report an exit which raced with Close rather than losing it.
+			if si, err := f.rawTryWaitid(options, ru); err == nil && si != nil {
+				return si, nil
+			}
 
-	go func() {
-		defer wg.Done()
+			return nil, cerr
+		}
 
-		// Immediately unblock pending reads.
-		<-ctx.Done()
-		_ = f.c.SetReadDeadline(time.Unix(0, 1))
-	}()
+		return nil, err
+	}
 
-	var si unix.Siginfo
-	rerr := f.c.Waitid(unix.P_PIDFD, &si, unix.WEXITED|unix.WNOWAIT, nil)
+	return &si, nil
+}
 
-	// The operation has unblocked. Observe context cancelation, tidy up the
-	// cancelation goroutine, and disarm the read deadline timer.
+// rawTryWaitid performs a nonblocking waitid(2) for a state change specified
+// by options in the process referred to by File, bypassing the runtime
+// poller. It returns nil siginfo if no such state change has occurred. Unlike
+// tryWaitInfo, it does not check whether File is closed. If ru is not nil, it
+// receives the resource usage of the process.
+func (f *File) rawTryWaitid(options int, ru *unix.Rusage) (*unix.Siginfo, error) {
+	var (
+		si  unix.Siginfo
+		err error
+	)
+	cerr := f.rc.Control(func(fd uintptr) {
+		for {
+			err = unix.Waitid(unix.P_PIDFD, int(fd), &si, options|unix.WNOHANG, ru)
+			if err != unix.EINTR {
+				return
+			}
+		}
+	})
+	if cerr != nil {
+		return nil, f.wrap(cerr)
+	}
+	if err != nil {
+		return nil, f.wrap(os.NewSyscallError("waitid", err))
+	}
+
+	// From waitid(2): "if WNOHANG was specified in options and there were no
+	// children in a waitable state, then waitid() returns 0 immediately and
+	// the state of the siginfo_t structure pointed to by infop depends on the
+	// implementation." Linux zeroes the structure.
+	if sigchldFields(&si).Pid == 0 {
+		return nil, nil
+	}
+
+	return &si, nil
+}
+
+// waitReadable waits for the pidfd to become readable, which indicates that
+// the process referred to by File has exited. Unlike waitid(2), this does not
+// require the process to be a child of the caller, and it succeeds even after
+// the process is reaped.
+func (f *File) waitReadable(ctx context.Context) error {
+	c, err := f.conn()
+	if err != nil {
+		return err
+	}
+
+	// The conn's RawConn must be used to wait for readiness, because the
+	// pidfd of a File opened by OpenSignalOnly is not pollable.
+	rc, err := c.SyscallConn()
+	if err != nil {
+		return f.wrap(err)
+	}
+
+	err = readContext(ctx, c, func() error {
+		var err error
+		rerr := rc.Read(func(fd uintptr) bool {
+			fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+			var n int
+			n, err = unix.Poll(fds, 0)
+			if err == unix.EINTR {
+				return false
+			}
+
+			// Not ready: wait for the runtime poller to report readiness.
+			return err != nil || n > 0
+		})
+		if rerr != nil {
+			return rerr
+		}
+
+		return f.wrap(os.NewSyscallError("poll", err))
+	})
+	if err != nil {
+		// The File may have been closed while waiting.
+		if cerr := f.checkOpen(); cerr != nil {
+			return cerr
+		}
+	}
+
+	return err
+}
+
+// readContext executes fn, a blocking read operation on c, and unblocks fn if
+// ctx is canceled.
+func readContext(ctx context.Context, c *conn, fn func() error) error {
+	// To observe context cancelation, set a past deadline when ctx is done to
+	// force blocked Reads to unblock. The runtime poller does the waiting, so
+	// no goroutine is needed unless ctx is actually canceled.
+	unblocked := make(chan struct{})
+	stop := afterFunc(ctx, func() {
+		defer close(unblocked)
+		_ = c.SetReadDeadline(time.Unix(0, 1))
+	})
+
+	rerr := fn()
+
+	// The operation has unblocked. Make sure a concurrent cancelation has
+	// finished setting its deadline, observe context cancelation, and disarm
+	// the read deadline timer. The caller owns the conn, so there is no prior
+	// deadline to restore.
+	if !stop() {
+		<-unblocked
+	}
 	cerr := ctx.Err()
-	cancel()
-	wg.Wait()
-	serr := f.c.SetReadDeadline(time.Time{})
+	serr := c.SetReadDeadline(time.Time{})
 
 	// Context cancel takes priority over all other errors.
 	for _, err := range []error{cerr, rerr, serr} {
@@ -112,6 +493,10 @@ func (f *File) wrap(err error) error {
 	if err == nil {
 		return nil
 	}
+	if cerr := f.checkOpen(); cerr != nil {
+		// Report a closed File rather than the resulting EBADF or similar.
+		return cerr
+	}
 
 	// Best effort.
 	var fd int
@@ -5,8 +5,11 @@ package pidfd_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"runtime"
 	"strconv"
 	"syscall"
 	"testing"
@@ -14,6 +17,7 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/mdlayher/pidfd"
+	"github.com/mdlayher/socket"
 	"golang.org/x/sys/unix"
 )
 
@@ -112,6 +116,39 @@ func TestFileSendSignalInitErrors(t *testing.T) {
 	}
 }
 
+func TestFileSendSignalExitedErrors(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	// A zombie process can still be signaled, so the process must also be
+	// reaped before it no longer exists.
+	testReap(t, cmd)
+
+	var perr *pidfd.Error
+	err := f.SendSignal(unix.SIGTERM)
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *pidfd.Error, but got: %v", err)
+	}
+
+	// Verify errors.Is wrapping.
+	if !errors.Is(err, os.ErrNotExist) || !errors.Is(perr, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+
+	// Verify the underlying error.
+	want := &pidfd.Error{
+		PID: cmd.Process.Pid,
+		// Copy FD; we don't care about the actual number.
+		FD:  perr.FD,
+		Err: os.NewSyscallError("pidfd_send_signal", unix.ESRCH),
+	}
+
+	if diff := cmp.Diff(want, perr); diff != "" {
+		t.Fatalf("unexpected error (-want +got):\n%s", diff)
+	}
+}
+
 func TestFileWaitProcessExitOK(t *testing.T) {
 	t.Parallel()
 
@@ -192,6 +229,112 @@ func TestOpenNotExist(t *testing.T) {
 	}
 }
 
+func TestOpenUntil(t *testing.T) {
+	t.Parallel()
+
+	f, err := pidfd.OpenUntil(time.Now().Add(1*time.Second), os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to open self: %v", err)
+	}
+	_ = f.Close()
+
+	const wait = 50 * time.Millisecond
+	start := time.Now()
+	if _, err := pidfd.OpenUntil(start.Add(wait), 12345678); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+	if d := time.Since(start); d < wait {
+		t.Fatalf("expected retries until deadline, but returned after %s", d)
+	}
+
+	// Other errors are not retried.
+	if _, err := pidfd.OpenUntil(time.Now().Add(1*time.Minute), -1); !errors.Is(err, unix.EINVAL) {
+		t.Fatalf("expected EINVAL, but got: %v", err)
+	}
+}
+
+func TestFileSendSignalAllThreads(t *testing.T) {
+	// Not parallel: a signal is sent to the test process.
+
+	f, err := pidfd.OpenSelf()
+	if err != nil {
+		t.Fatalf("failed to open self: %v", err)
+	}
+	defer f.Close()
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, unix.SIGUSR2)
+	defer signal.Stop(sigC)
+
+	// The Go runtime always runs multiple threads.
+	if err := f.SendSignalAllThreads(unix.SIGUSR2); err != nil {
+		t.Fatalf("failed to signal all threads: %v", err)
+	}
+
+	select {
+	case <-sigC:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for signal")
+	}
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+	testReap(t, cmd)
+
+	if err := f.SendSignalAllThreads(unix.SIGUSR2); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestOpenTraced(t *testing.T) {
+	t.Parallel()
+
+	f, trace, err := pidfd.OpenTraced(time.Now().Add(1*time.Second), os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to open self: %v", err)
+	}
+	_ = f.Close()
+
+	if trace.Retries != 0 {
+		t.Fatalf("expected no retries, but got: %d", trace.Retries)
+	}
+
+	const wait = 50 * time.Millisecond
+	_, trace, err = pidfd.OpenTraced(time.Now().Add(wait), 12345678)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+	if trace.Retries == 0 || trace.Elapsed < wait {
+		t.Fatalf("expected retries until deadline, but got: %+v", trace)
+	}
+}
+
+func TestOpenSelf(t *testing.T) {
+	// Not parallel: a signal is sent to the test process.
+
+	f, err := pidfd.OpenSelf()
+	if err != nil {
+		t.Fatalf("failed to open self: %v", err)
+	}
+	defer f.Close()
+
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, unix.SIGUSR2)
+	defer signal.Stop(sigC)
+
+	if err := f.SendSignal(unix.SIGUSR2); err != nil {
+		t.Fatalf("failed to signal self: %v", err)
+	}
+
+	select {
+	case sig := <-sigC:
+		if diff := cmp.Diff(os.Signal(unix.SIGUSR2), sig); diff != "" {
+			t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for signal")
+	}
+}
+
 func testSleepFile(t *testing.T, d time.Duration) (context.Context, *pidfd.File, *exec.Cmd) {
 	t.Helper()
 
@@ -221,3 +364,738 @@ func testSleepFile(t *testing.T, d time.Duration) (context.Context, *pidfd.File,
 
 	return ctx, f, cmd
 }
+
+func testReap(t *testing.T, cmd *exec.Cmd) {
+	t.Helper()
+
+	// Forcibly terminate and reap the process so that it no longer exists.
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatalf("failed to kill child process: %v", err)
+	}
+
+	var eerr *exec.ExitError
+	if err := cmd.Wait(); !errors.As(err, &eerr) {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+}
+
+func TestCloseAll(t *testing.T) {
+	t.Parallel()
+
+	_, f1, _ := testSleepFile(t, 1*time.Hour)
+	_, f2, _ := testSleepFile(t, 1*time.Hour)
+
+	if err := pidfd.CloseAll(f1, nil, f2); err != nil {
+		t.Fatalf("failed to close all files: %v", err)
+	}
+
+	// Both Files are closed and can no longer be used.
+	for i, f := range []*pidfd.File{f1, f2} {
+		if err := f.SendSignal(unix.SIGTERM); !errors.Is(err, os.ErrClosed) {
+			t.Fatalf("expected closed error for file %d, but got: %v", i, err)
+		}
+	}
+}
+
+func TestFileWaitUntilSignalExited(t *testing.T) {
+	// Not parallel: TestFileWaitUntilSignalReceived sends SIGUSR1 to the
+	// entire process.
+
+	ctx, f, _ := testSleepFile(t, 1*time.Second)
+
+	exited, sig, err := f.WaitUntilSignal(ctx, unix.SIGUSR1)
+	if err != nil {
+		t.Fatalf("failed to wait for child process exit: %v", err)
+	}
+	if !exited || sig != nil {
+		t.Fatalf("expected exit without signal, but got: %v, %v", exited, sig)
+	}
+}
+
+func TestFileWaitUntilSignalReceived(t *testing.T) {
+	// Not parallel: SIGUSR1 is sent to the entire process.
+
+	// Ensure SIGUSR1 can never terminate the test binary, even if it is
+	// delivered before WaitUntilSignal subscribes to it.
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, unix.SIGUSR1)
+	defer signal.Stop(sigC)
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		tick := time.NewTicker(50 * time.Millisecond)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+				_ = unix.Kill(os.Getpid(), unix.SIGUSR1)
+			}
+		}
+	}()
+
+	exited, sig, err := f.WaitUntilSignal(ctx, unix.SIGUSR1)
+	if err != nil {
+		t.Fatalf("failed to wait for signal: %v", err)
+	}
+	if exited {
+		t.Fatal("expected child process to still be running")
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGUSR1), sig); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+}
+
+func TestOpenFDExhausted(t *testing.T) {
+	// Not parallel: the file descriptor limit applies to the entire process.
+	var rlim unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NOFILE, &rlim); err != nil {
+		t.Fatalf("failed to get file descriptor limit: %v", err)
+	}
+
+	// Standard input, output, and error occupy the lowest file descriptors
+	// so no new descriptors can be allocated with this limit.
+	low := rlim
+	low.Cur = 3
+	if err := unix.Setrlimit(unix.RLIMIT_NOFILE, &low); err != nil {
+		t.Skipf("skipping, failed to lower file descriptor limit: %v", err)
+	}
+
+	_, err := pidfd.Open(os.Getpid())
+
+	if err := unix.Setrlimit(unix.RLIMIT_NOFILE, &rlim); err != nil {
+		t.Fatalf("failed to restore file descriptor limit: %v", err)
+	}
+
+	if !errors.Is(err, pidfd.ErrFDExhausted) {
+		t.Fatalf("expected file descriptors exhausted, but got: %v", err)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("file descriptors exhausted must not be not exist: %v", err)
+	}
+}
+
+func BenchmarkOpen(b *testing.B) {
+	pid := os.Getpid()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		f, err := pidfd.Open(pid)
+		if err != nil {
+			b.Fatalf("failed to open pidfd: %v", err)
+		}
+		_ = f.Close()
+	}
+}
+
+func TestErrorEqual(t *testing.T) {
+	t.Parallel()
+
+	base := &pidfd.Error{
+		FD:  3,
+		PID: 1,
+		Err: os.NewSyscallError("pidfd_send_signal", unix.EPERM),
+	}
+
+	tests := []struct {
+		name string
+		e    *pidfd.Error
+		ok   bool
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name: "identical",
+			e:    base,
+			ok:   true,
+		},
+		{
+			name: "bare errno",
+			e:    &pidfd.Error{FD: 3, PID: 1, Err: unix.EPERM},
+			ok:   true,
+		},
+		{
+			name: "FD",
+			e:    &pidfd.Error{FD: 4, PID: 1, Err: unix.EPERM},
+		},
+		{
+			name: "PID",
+			e:    &pidfd.Error{FD: 3, PID: 2, Err: unix.EPERM},
+		},
+		{
+			name: "errno",
+			e:    &pidfd.Error{FD: 3, PID: 1, Err: unix.ESRCH},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.ok, base.Equal(tt.e)); diff != "" {
+				t.Fatalf("unexpected Equal result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestErrorPermissionCause(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		e    *pidfd.Error
+		want string
+	}{
+		{
+			name: "unknown",
+			e:    &pidfd.Error{FD: 3, PID: 1, Err: unix.EPERM},
+			want: "pidfd 3: pid: 1: operation not permitted",
+		},
+		{
+			name: "capability",
+			e: &pidfd.Error{
+				PID:             1,
+				Err:             unix.EPERM,
+				PermissionCause: pidfd.PermissionCauseCapability,
+			},
+			want: "pidfd 0: pid: 1: operation not permitted (missing CAP_SYS_PTRACE)",
+		},
+		{
+			name: "ptrace scope",
+			e: &pidfd.Error{
+				PID:             1,
+				Err:             unix.EPERM,
+				TraceID:         "abc123",
+				PermissionCause: pidfd.PermissionCausePtraceScope,
+			},
+			want: "pidfd 0: pid: 1: trace abc123: operation not permitted (restricted by kernel.yama.ptrace_scope)",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, tt.e.Error()); diff != "" {
+				t.Fatalf("unexpected error string (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFileCancelWait(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	// No wait in progress, so this must not affect the next wait.
+	if err := f.CancelWait(); err != nil {
+		t.Fatalf("failed to cancel idle wait: %v", err)
+	}
+
+	timer := time.AfterFunc(100*time.Millisecond, func() { _ = f.CancelWait() })
+	defer timer.Stop()
+
+	if err := f.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled for wait, but got: %v", err)
+	}
+
+	// The File remains usable after CancelWait.
+	if err := f.SendSignal(unix.SIGTERM); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for child process exit: %v", err)
+	}
+}
+
+func TestNewFileConn(t *testing.T) {
+	t.Parallel()
+
+	ctx, _, cmd := testSleepFile(t, 1*time.Hour)
+
+	fd, err := unix.PidfdOpen(cmd.Process.Pid, 0)
+	if err != nil {
+		t.Fatalf("failed to open pidfd: %v", err)
+	}
+
+	c, err := socket.New(fd, "pidfd")
+	if err != nil {
+		t.Fatalf("failed to create conn: %v", err)
+	}
+
+	f, err := pidfd.NewFileConn(c, cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to create File: %v", err)
+	}
+	defer f.Close()
+
+	if err := f.SendSignal(unix.SIGTERM); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for child process exit: %v", err)
+	}
+}
+
+func TestFileWaitTraceID(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	ctx, cancel := context.WithCancel(pidfd.WithTraceID(ctx, "abc123"))
+	cancel()
+
+	err := f.Wait(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled for wait, but got: %v", err)
+	}
+
+	var perr *pidfd.Error
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *pidfd.Error, but got: %v", err)
+	}
+
+	if perr.TraceID != "abc123" || perr.PID != cmd.Process.Pid {
+		t.Fatalf("unexpected Error: %#v", perr)
+	}
+}
+
+func TestFileCanSignal(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	ok, err := f.CanSignal()
+	if err != nil {
+		t.Fatalf("failed to check signal permission: %v", err)
+	}
+	if !ok {
+		t.Fatal("expected permission to signal child process")
+	}
+
+	testReap(t, cmd)
+
+	ok, err = f.CanSignal()
+	if ok || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v, %v", ok, err)
+	}
+}
+
+func TestFileIsStillPermitted(t *testing.T) {
+	t.Parallel()
+
+	if os.Getuid() != 0 {
+		t.Skip("skipping, requires root")
+	}
+
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	ok, err := f.IsStillPermitted()
+	if err != nil || !ok {
+		t.Fatalf("expected permission while privileged, but got: %v, %v", ok, err)
+	}
+
+	// Drop privileges on a single thread using the raw system call, rather
+	// than for the entire test process as syscall.Setresuid would. The saved
+	// user ID remains root so that privileges can be restored.
+	const nobody = 65534
+
+	var (
+		killed = make(chan struct{})
+		errC   = make(chan error, 1)
+	)
+
+	go func() {
+		// The thread is never unlocked, so it exits with the goroutine
+		// rather than being reused if privileges cannot be restored.
+		runtime.LockOSThread()
+
+		if _, _, errno := unix.RawSyscall(unix.SYS_SETRESUID, nobody, nobody, 0); errno != 0 {
+			errC <- fmt.Errorf("failed to drop privileges: %v", errno)
+			return
+		}
+		defer func() { _, _, _ = unix.RawSyscall(unix.SYS_SETRESUID, 0, 0, 0) }()
+
+		ok, err := f.IsStillPermitted()
+		if err != nil || ok {
+			errC <- fmt.Errorf("expected no permission after dropping privileges, but got: %v, %v", ok, err)
+			return
+		}
+		if err := f.SendSignal(unix.SIGKILL); !errors.Is(err, os.ErrPermission) {
+			errC <- fmt.Errorf("expected permission denied for signal, but got: %v", err)
+			return
+		}
+		errC <- nil
+
+		// The File can still be used to wait for the process.
+		<-killed
+		wi, err := f.Peek(ctx)
+		if err == nil && wi.Signal() != unix.SIGKILL {
+			err = fmt.Errorf("unexpected WaitInfo: %+v", wi)
+		}
+		errC <- err
+	}()
+
+	if err := <-errC; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatalf("failed to kill child process: %v", err)
+	}
+	close(killed)
+
+	if err := <-errC; err != nil {
+		t.Fatalf("failed to wait after dropping privileges: %v", err)
+	}
+}
+
+func TestOpenProcess(t *testing.T) {
+	t.Parallel()
+
+	ctx, _, cmd := testSleepFile(t, 1*time.Hour)
+
+	f, err := pidfd.OpenProcess(cmd.Process)
+	if err != nil {
+		t.Fatalf("failed to open process: %v", err)
+	}
+	defer f.Close()
+
+	if err := f.SendSignal(unix.SIGTERM); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for child process exit: %v", err)
+	}
+}
+
+func TestFileEpollEvent(t *testing.T) {
+	t.Parallel()
+
+	_, f, _ := testSleepFile(t, 1*time.Hour)
+
+	epfd, err := unix.EpollCreate1(unix.EPOLL_CLOEXEC)
+	if err != nil {
+		t.Fatalf("failed to create epoll: %v", err)
+	}
+	defer unix.Close(epfd)
+
+	fev := f.EpollEvent()
+	ev := unix.EpollEvent{Events: fev.Events | unix.EPOLLET, Fd: fev.Fd}
+	if err := unix.EpollCtl(epfd, unix.EPOLL_CTL_ADD, int(ev.Fd), &ev); err != nil {
+		t.Fatalf("failed to add pidfd to epoll: %v", err)
+	}
+
+	events := make([]unix.EpollEvent, 1)
+	if n, err := unix.EpollWait(epfd, events, 0); err != nil || n != 0 {
+		t.Fatalf("expected no events before exit, but got: %d, %v", n, err)
+	}
+
+	if err := f.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	n, err := unix.EpollWait(epfd, events, 10_000)
+	if err != nil || n != 1 {
+		t.Fatalf("expected exit event, but got: %d, %v", n, err)
+	}
+	if events[0].Fd != ev.Fd || events[0].Events&unix.EPOLLIN == 0 {
+		t.Fatalf("unexpected event: %+v", events[0])
+	}
+
+	_ = unix.EpollCtl(epfd, unix.EPOLL_CTL_DEL, int(ev.Fd), nil)
+	_ = f.Close()
+	if fd := f.EpollEvent().Fd; fd != -1 {
+		t.Fatalf("expected -1 file descriptor after close, but got: %d", fd)
+	}
+}
+
+func TestFileClosed(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	// Close the File while a Wait is in progress.
+	errC := make(chan error, 1)
+	go func() { errC <- f.Wait(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to close File: %v", err)
+	}
+
+	if err := <-errC; !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("expected closed error for in-flight Wait, but got: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{
+			name: "Wait",
+			fn:   func() error { return f.Wait(ctx) },
+		},
+		{
+			name: "SendSignal",
+			fn:   func() error { return f.SendSignal(unix.SIGTERM) },
+		},
+		{
+			name: "StartTime",
+			fn: func() error {
+				_, err := f.StartTime()
+				return err
+			},
+		},
+		{
+			name: "Info",
+			fn: func() error {
+				_, err := f.Info()
+				return err
+			},
+		},
+		{
+			name: "WaitPoll",
+			fn: func() error {
+				_, err := f.WaitPoll(ctx, time.Millisecond)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if err := tt.fn(); !errors.Is(err, os.ErrClosed) {
+			t.Fatalf("%s: expected closed error, but got: %v", tt.name, err)
+		}
+	}
+}
+
+func TestFileCloseWaitConcurrent(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 20; i++ {
+		ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+
+		type result struct {
+			wi  *pidfd.WaitInfo
+			err error
+		}
+
+		resC := make(chan result, 1)
+		go func() {
+			wi, err := f.Peek(ctx)
+			resC <- result{wi: wi, err: err}
+		}()
+
+		// Race the process's exit against Close.
+		if err := cmd.Process.Kill(); err != nil {
+			t.Fatalf("failed to kill child process: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("failed to close File: %v", err)
+		}
+
+		// Close waits for the in-flight Peek to return, unless Peek had not
+		// yet begun waiting when Close was called.
+		var res result
+		select {
+		case res = <-resC:
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for Peek")
+		}
+
+		switch {
+		case res.err == nil:
+			if diff := cmp.Diff(os.Signal(unix.SIGKILL), res.wi.Signal()); diff != "" {
+				t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+			}
+		case !errors.Is(res.err, os.ErrClosed):
+			t.Fatalf("expected exit or closed error, but got: %v", res.err)
+		}
+	}
+}
+
+func TestOpenSignalOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx, _, cmd := testSleepFile(t, 1*time.Hour)
+
+	f, err := pidfd.OpenSignalOnly(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to open signal-only File: %v", err)
+	}
+	defer f.Close()
+
+	if ok, err := f.CanSignal(); !ok || err != nil {
+		t.Fatalf("expected permission to signal, but got: %v, %v", ok, err)
+	}
+
+	// Waiting upgrades the File, and is canceled while the process is alive.
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	if err := f.Wait(tctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, but got: %v", err)
+	}
+
+	if err := f.SendSignal(unix.SIGTERM); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for child process exit: %v", err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to close File: %v", err)
+	}
+	if err := f.SendSignal(unix.SIGTERM); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("expected closed error, but got: %v", err)
+	}
+}
+
+func TestFileBadDescriptor(t *testing.T) {
+	// Not parallel: the pidfd's file descriptor number is closed out from
+	// under the File and must not be reused by other tests until it is
+	// restored.
+	_, f, _ := testSleepFile(t, 1*time.Hour)
+
+	fd := int(f.EpollEvent().Fd)
+	if err := unix.Close(fd); err != nil {
+		t.Fatalf("failed to close pidfd: %v", err)
+	}
+
+	serr := f.SendSignal(unix.SIGTERM)
+	_, terr := f.TryWait()
+	_, cerr := f.Consume()
+
+	// Occupy the file descriptor number again so that closing the File
+	// cannot close an unrelated file. The File takes ownership of it.
+	null, nerr := unix.Open(os.DevNull, unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if nerr != nil {
+		t.Fatalf("failed to open null device: %v", nerr)
+	}
+	if null != fd {
+		if nerr := unix.Dup3(null, fd, unix.O_CLOEXEC); nerr != nil {
+			t.Fatalf("failed to restore file descriptor: %v", nerr)
+		}
+		_ = unix.Close(null)
+	}
+
+	for _, tt := range []struct {
+		name string
+		err  error
+	}{
+		{name: "send signal", err: serr},
+		{name: "try wait", err: terr},
+		{name: "consume", err: cerr},
+	} {
+		var perr *pidfd.Error
+		if !errors.As(tt.err, &perr) {
+			t.Fatalf("%s: expected *pidfd.Error, but got: %v", tt.name, tt.err)
+		}
+		if !errors.Is(tt.err, pidfd.ErrBadDescriptor) {
+			t.Fatalf("%s: expected bad descriptor, but got: %v", tt.name, tt.err)
+		}
+		if errors.Is(tt.err, os.ErrNotExist) {
+			t.Fatalf("%s: bad descriptor must not be reported as not exist", tt.name)
+		}
+	}
+}
+
+func TestOpenWithInheritable(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	inh, err := pidfd.Open(cmd.Process.Pid, pidfd.WithInheritable())
+	if err != nil {
+		t.Fatalf("failed to open inheritable pidfd: %v", err)
+	}
+	defer inh.Close()
+
+	for _, tt := range []struct {
+		name string
+		f    *pidfd.File
+		want int
+	}{
+		{name: "default", f: f, want: unix.FD_CLOEXEC},
+		{name: "inheritable", f: inh, want: 0},
+	} {
+		flags, err := unix.FcntlInt(uintptr(tt.f.EpollEvent().Fd), unix.F_GETFD, 0)
+		if err != nil {
+			t.Fatalf("%s: failed to get descriptor flags: %v", tt.name, err)
+		}
+
+		if got := flags & unix.FD_CLOEXEC; got != tt.want {
+			t.Fatalf("%s: unexpected close-on-exec flag: want: %d, got: %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestOpenWithDone(t *testing.T) {
+	t.Parallel()
+
+	ctx, _, cmd := testSleepFile(t, 1*time.Hour)
+
+	f, done, err := pidfd.OpenWithDone(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to open with done: %v", err)
+	}
+	defer f.Close()
+
+	select {
+	case <-done:
+		t.Fatal("done closed before process exit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := f.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatalf("done not closed after process exit: %v", ctx.Err())
+	}
+}
+
+func TestOpenWithDoneClose(t *testing.T) {
+	t.Parallel()
+
+	_, _, cmd := testSleepFile(t, 1*time.Hour)
+
+	f, done, err := pidfd.OpenWithDone(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to open with done: %v", err)
+	}
+
+	// Close stops the background goroutine without closing done.
+	if err := f.Close(); err != nil {
+		t.Fatalf("failed to close File: %v", err)
+	}
+
+	select {
+	case <-done:
+		t.Fatal("done closed for running process")
+	default:
+	}
+}
@@ -11,17 +11,44 @@ import (
 	"time"
 )
 
-var esrch = errors.New("")
+var (
+	esrch  = errors.New("")
+	emfile = errors.New("")
+	enfile = errors.New("")
+	ebadf  = errors.New("")
+)
 
 // errUnimplemented is returned by all functions on non-Linux platforms.
 var errUnimplemented = fmt.Errorf("pidfd: not implemented on %s", runtime.GOOS)
 
-func open(_ int) (*File, error) { return nil, errUnimplemented }
+// enosys reports errUnimplemented as unsupported.
+var enosys = errUnimplemented
+
+func errnoName(_ error) string { return "" }
+
+func open(_ int) (*File, error)           { return nil, errUnimplemented }
+func openSignalOnly(_ int) (*File, error) { return nil, errUnimplemented }
 
 type conn struct{}
 
-func (*File) sendSignal(_ os.Signal) error { return errUnimplemented }
-func (*File) wait(_ context.Context) error { return errUnimplemented }
+// A Conn wraps a pidfd for use with NewFileConn. pidfds are only supported on
+// Linux.
+type Conn struct{}
+
+func newFileConn(_ *Conn, _ int) (*File, error) { return nil, errUnimplemented }
+
+func (*File) wrap(err error) error { return err }
+
+func (*File) epollEvent() EpollEvent { return EpollEvent{Fd: -1} }
+
+func (f *File) close() error        { return f.c.Close() }
+func (*File) setInheritable() error { return errUnimplemented }
+
+func (*File) sendSignal(_ os.Signal) error           { return errUnimplemented }
+func (*File) signalZero() error                      { return errUnimplemented }
+func (*File) sendSignalAllThreads(_ os.Signal) error { return errUnimplemented }
+func (*File) wait(_ context.Context) error           { return errUnimplemented }
+func (*File) waitReadable(_ context.Context) error   { return errUnimplemented }
 
 func (*conn) Close() error                      { return errUnimplemented }
 func (*conn) SetReadDeadline(_ time.Time) error { return errUnimplemented }
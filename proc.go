@@ -0,0 +1,132 @@
+package pidfd
+
+import "io/fs"
+
+// OpenChildren opens Files referring to each of the calling process's child
+// processes, such as to resume supervision of children after a restart.
+// Children which exit during enumeration are skipped. If any other child
+// cannot be opened, OpenChildren returns the Files which were opened along
+// with the joined errors for the remaining children.
+//
+// Children are found by scanning /proc, so children started concurrently may
+// be missed.
+func OpenChildren() ([]*File, error) { return openChildren() }
+
+// StartTime returns the time the process referred to by File started after
+// system boot, expressed in clock ticks. The value never changes for the
+// lifetime of a process, so it may be stored alongside a pid to detect pid
+// reuse at a later time.
+func (f *File) StartTime() (uint64, error) { return f.startTime() }
+
+// OOMScoreAdj returns the OOM killer score adjustment of the process referred
+// to by File, from -1000 to 1000.
+func (f *File) OOMScoreAdj() (int, error) { return f.oomScoreAdj() }
+
+// SetOOMScoreAdj sets the OOM killer score adjustment of the process referred
+// to by File, from -1000 to 1000. Lowering the adjustment below its minimum
+// value requires CAP_SYS_RESOURCE; if permission is denied, an *Error
+// compatible with errors.Is(err, os.ErrPermission) is returned.
+func (f *File) SetOOMScoreAdj(v int) error { return f.setOOMScoreAdj(v) }
+
+// NumFDs returns the number of file descriptors open in the process referred
+// to by File. The process may open and close file descriptors at any time, so
+// the count is a snapshot. Reading another user's process's file descriptors
+// requires privileges.
+func (f *File) NumFDs() (int, error) { return f.numFDs() }
+
+// SocketCounts returns the number of sockets open in the process referred to
+// by File, keyed by protocol: "tcp", "tcp6", "udp", "udp6", "udplite",
+// "udplite6", "raw", "raw6", "unix", "netlink", or "packet". Sockets are
+// classified using the tables in /proc/<pid>/net, which only list sockets in
+// the process's network namespace; sockets which can't be classified, such as
+// those created in another network namespace, are counted as "other". As with
+// NumFDs, the counts are a snapshot. Reading another user's process's file
+// descriptors requires privileges.
+func (f *File) SocketCounts() (map[string]int, error) { return f.socketCounts() }
+
+// TTY returns the device path of the controlling terminal of the process
+// referred to by File, such as "/dev/pts/3". If the process has no
+// controlling terminal, TTY returns the empty string.
+func (f *File) TTY() (string, error) { return f.tty() }
+
+// Environ returns the environment of the process referred to by File, in the
+// form "key=value", as of when the process was started or last modified its
+// own environment block. Reading another user's process's environment
+// requires privileges; if permission is denied, an *Error compatible with
+// errors.Is(err, os.ErrPermission) is returned.
+func (f *File) Environ() ([]string, error) { return f.environ() }
+
+// Cwd returns the current working directory of the process referred to by
+// File. Reading another user's process's working directory requires
+// privileges; if permission is denied, an *Error compatible with
+// errors.Is(err, os.ErrPermission) is returned. If the process has exited,
+// the *Error is compatible with errors.Is(err, os.ErrNotExist).
+func (f *File) Cwd() (string, error) { return f.cwd() }
+
+// Exe returns the path of the executable file of the process referred to by
+// File, as it was resolved when the process last called execve(2). If the
+// file has since been removed, the path has the suffix " (deleted)". Reading
+// another user's process's executable requires privileges; if permission is
+// denied, an *Error compatible with errors.Is(err, os.ErrPermission) is
+// returned.
+func (f *File) Exe() (string, error) { return f.exe() }
+
+// ExeChanged reports whether the executable file of the process referred to by
+// File differs from since, typically a path previously returned by Exe when
+// the process was vetted. It is intended for authorization flows which
+// re-verify a process before granting it a resource, as a process may call
+// execve(2) to run a different program at any time. If the process has
+// exited, an *Error compatible with errors.Is(err, os.ErrNotExist) is
+// returned.
+//
+// ExeChanged compares paths, so it does not report a process which executed
+// the same path again, even if the file at that path was replaced.
+func (f *File) ExeChanged(since string) (bool, error) {
+	exe, err := f.Exe()
+	if err != nil {
+		return false, err
+	}
+
+	return exe != since, nil
+}
+
+// ContextSwitches returns the number of voluntary and involuntary context
+// switches performed by the main thread of the process referred to by File, as
+// reported by /proc/<pid>/status. Context switches performed by other threads
+// are not included.
+func (f *File) ContextSwitches() (voluntary, involuntary uint64, err error) {
+	return f.contextSwitches()
+}
+
+// IsKernelThread reports whether the process referred to by File is a kernel
+// thread, which has no user space memory, command line, or executable.
+// Monitoring tools may use IsKernelThread to skip kernel threads.
+func (f *File) IsKernelThread() (bool, error) { return f.isKernelThread() }
+
+// ProcFS returns a file system rooted at the /proc/<pid> directory of the
+// process referred to by File, such as for reading files which are not
+// otherwise exposed by this package with fs.ReadFile(fsys, "status").
+//
+// The pid may be reused by another process once the original process exits
+// and is reaped, so the file system uses the pidfd to check that the process
+// still exists after opening or reading each file, and returns an *Error
+// compatible with errors.Is(err, os.ErrNotExist) if it does not. A file which
+// was opened while the process existed continues to refer to that process.
+func (f *File) ProcFS() (fs.FS, error) { return f.procFS() }
+
+// TracerPID returns the pid of the process which is tracing the process
+// referred to by File with ptrace(2), such as a debugger, or zero if the
+// process is not being traced. Monitors may use TracerPID to detect an
+// unexpected ptrace attachment to their children.
+func (f *File) TracerPID() (int, error) { return f.tracerPID() }
+
+// Seccomp modes for SeccompMode, as described in seccomp(2).
+const (
+	SeccompModeDisabled = 0
+	SeccompModeStrict   = 1
+	SeccompModeFilter   = 2
+)
+
+// SeccompMode returns the seccomp mode of the process referred to by File,
+// such as SeccompModeFilter if the process has installed a seccomp filter.
+func (f *File) SeccompMode() (int, error) { return f.seccompMode() }
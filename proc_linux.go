@@ -0,0 +1,642 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// openChildren implements OpenChildren.
+func openChildren() ([]*File, error) {
+	pids, err := children(os.Getpid())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		files = make([]*File, 0, len(pids))
+		errs  []error
+	)
+	for _, pid := range pids {
+		f, err := Open(pid)
+		switch {
+		case err == nil:
+			files = append(files, f)
+		case errors.Is(err, os.ErrNotExist):
+			// Exited and reaped during enumeration.
+		default:
+			errs = append(errs, err)
+		}
+	}
+
+	return files, errors.Join(errs...)
+}
+
+// startTime implements File.StartTime.
+func (f *File) startTime() (uint64, error) {
+	// From proc(5): "(22) starttime %llu: The time the process started after
+	// system boot."
+	s, err := f.statField(22)
+	if err != nil {
+		return 0, err
+	}
+
+	return f.parseUint(s)
+}
+
+// oomScoreAdj implements File.OOMScoreAdj.
+func (f *File) oomScoreAdj() (int, error) {
+	b, err := f.readProc("oom_score_adj")
+	if err != nil {
+		return 0, err
+	}
+
+	return f.parseInt(strings.TrimSpace(string(b)))
+}
+
+// setOOMScoreAdj implements File.SetOOMScoreAdj.
+func (f *File) setOOMScoreAdj(v int) error {
+	return f.writeProc("oom_score_adj", []byte(strconv.Itoa(v)))
+}
+
+// numFDs implements File.NumFDs.
+func (f *File) numFDs() (int, error) {
+	dir, err := os.Open(f.procPath("fd"))
+	if err != nil {
+		return 0, f.wrap(err)
+	}
+	defer dir.Close()
+
+	// Count entries in batches rather than allocating all names at once.
+	// Entries added or removed during the scan may or may not be counted.
+	var n int
+	for {
+		names, err := dir.Readdirnames(1024)
+		n += len(names)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, f.wrap(err)
+		}
+	}
+
+	if err := f.exists(); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// socketTables are the /proc/<pid>/net tables used to classify sockets by
+// protocol, with the zero-based column containing each socket's inode number.
+var socketTables = []struct {
+	name   string
+	column int
+}{
+	{name: "tcp", column: 9},
+	{name: "tcp6", column: 9},
+	{name: "udp", column: 9},
+	{name: "udp6", column: 9},
+	{name: "udplite", column: 9},
+	{name: "udplite6", column: 9},
+	{name: "raw", column: 9},
+	{name: "raw6", column: 9},
+	{name: "unix", column: 6},
+	{name: "netlink", column: 9},
+	{name: "packet", column: 8},
+}
+
+// socketCounts implements File.SocketCounts.
+func (f *File) socketCounts() (map[string]int, error) {
+	inodes, err := f.socketInodes()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, t := range socketTables {
+		if len(inodes) == 0 {
+			break
+		}
+
+		b, err := os.ReadFile(f.procPath("net/" + t.name))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// Protocol not supported by this kernel, or the process is
+				// gone, which is checked below.
+				continue
+			}
+
+			return nil, f.wrap(err)
+		}
+
+		// Skip the header line.
+		lines := strings.Split(string(b), "\n")
+		for _, l := range lines[1:] {
+			fields := strings.Fields(l)
+			if len(fields) <= t.column {
+				continue
+			}
+
+			ino := fields[t.column]
+			if inodes[ino] {
+				counts[t.name]++
+				delete(inodes, ino)
+			}
+		}
+	}
+
+	if len(inodes) > 0 {
+		counts["other"] = len(inodes)
+	}
+
+	if err := f.exists(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// socketInodes returns the set of inode numbers of the sockets open in the
+// process referred to by File.
+func (f *File) socketInodes() (map[string]bool, error) {
+	dir := f.procPath("fd")
+	des, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, f.wrap(err)
+	}
+
+	inodes := make(map[string]bool)
+	for _, de := range des {
+		link, err := os.Readlink(dir + "/" + de.Name())
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// Closed during the scan.
+				continue
+			}
+
+			return nil, f.wrap(err)
+		}
+
+		// Sockets are shown as "socket:[inode]".
+		if ino, ok := strings.CutPrefix(link, "socket:["); ok {
+			inodes[strings.TrimSuffix(ino, "]")] = true
+		}
+	}
+
+	return inodes, nil
+}
+
+// environ implements File.Environ.
+func (f *File) environ() ([]string, error) {
+	b, err := f.readProc("environ")
+	if err != nil {
+		return nil, err
+	}
+
+	// From proc(5): "The entries are separated by null bytes ('\0'), and
+	// there may be a null byte at the end."
+	s := strings.TrimSuffix(string(b), "\x00")
+	if s == "" {
+		return []string{}, nil
+	}
+
+	return strings.Split(s, "\x00"), nil
+}
+
+// cwd implements File.Cwd.
+func (f *File) cwd() (string, error) { return f.readlinkProc("cwd") }
+
+// exe implements File.Exe.
+func (f *File) exe() (string, error) { return f.readlinkProc("exe") }
+
+// contextSwitches implements File.ContextSwitches.
+func (f *File) contextSwitches() (uint64, uint64, error) {
+	kvs, err := f.procKeyValues("status")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	vol, err := f.parseUint(kvs["voluntary_ctxt_switches"])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	invol, err := f.parseUint(kvs["nonvoluntary_ctxt_switches"])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return vol, invol, nil
+}
+
+// pfKthread is the process flag for kernel threads, from linux/sched.h.
+const pfKthread = 0x00200000
+
+// isKernelThread implements File.IsKernelThread.
+func (f *File) isKernelThread() (bool, error) {
+	// The command line of a kernel thread is empty, but so is that of a
+	// zombie process or one which cleared its own arguments, so the process
+	// flags are checked instead.
+	//
+	// From proc(5): "(9) flags %u: The kernel flags word of the process."
+	s, err := f.statField(9)
+	if err != nil {
+		return false, err
+	}
+
+	flags, err := f.parseUint(s)
+	if err != nil {
+		return false, err
+	}
+
+	return flags&pfKthread != 0, nil
+}
+
+// seccompMode implements File.SeccompMode.
+func (f *File) seccompMode() (int, error) {
+	kvs, err := f.procKeyValues("status")
+	if err != nil {
+		return 0, err
+	}
+
+	// The field is only present if the kernel supports seccomp.
+	s, ok := kvs["Seccomp"]
+	if !ok {
+		return 0, f.wrap(errors.New(`missing status field "Seccomp"`))
+	}
+
+	return f.parseInt(s)
+}
+
+// tracerPID implements File.TracerPID.
+func (f *File) tracerPID() (int, error) {
+	kvs, err := f.procKeyValues("status")
+	if err != nil {
+		return 0, err
+	}
+
+	s, ok := kvs["TracerPid"]
+	if !ok {
+		return 0, f.wrap(errors.New(`missing status field "TracerPid"`))
+	}
+
+	return f.parseInt(s)
+}
+
+// Device major numbers of Unix 98 pseudoterminal replicas, from
+// Documentation/admin-guide/devices.txt.
+const (
+	ptsMajorFirst = 136
+	ptsMajorLast  = 143
+)
+
+// tty implements File.TTY.
+func (f *File) tty() (string, error) {
+	// From proc(5): "(7) tty_nr %d: The controlling terminal of the process.
+	// (The minor device number is contained in the combination of bits 31 to
+	// 20 and 7 to 0; the major device number is in bits 15 to 8.)"
+	s, err := f.statField(7)
+	if err != nil {
+		return "", err
+	}
+
+	nr, err := f.parseInt(s)
+	if err != nil {
+		return "", err
+	}
+	if nr == 0 {
+		// No controlling terminal.
+		return "", nil
+	}
+
+	var (
+		major = (nr >> 8) & 0xfff
+		minor = (nr & 0xff) | ((nr >> 12) & 0xfff00)
+	)
+
+	// Pseudoterminal replicas are not listed in sysfs.
+	if major >= ptsMajorFirst && major <= ptsMajorLast {
+		return fmt.Sprintf("/dev/pts/%d", (major-ptsMajorFirst)<<8|minor), nil
+	}
+
+	// Other terminals report their device name in sysfs, such as
+	// "DEVNAME=tty1".
+	b, err := os.ReadFile(fmt.Sprintf("/sys/dev/char/%d:%d/uevent", major, minor))
+	if err != nil {
+		return "", f.wrap(err)
+	}
+
+	for _, line := range strings.Split(string(b), "\n") {
+		if name, ok := strings.CutPrefix(line, "DEVNAME="); ok {
+			return "/dev/" + name, nil
+		}
+	}
+
+	return "", f.wrap(fmt.Errorf("no device name for terminal %d:%d", major, minor))
+}
+
+// procFS implements File.ProcFS.
+func (f *File) procFS() (fs.FS, error) {
+	if err := f.exists(); err != nil {
+		return nil, err
+	}
+
+	return &procFS{
+		f:    f,
+		fsys: os.DirFS(f.procPath("")),
+	}, nil
+}
+
+var _ fs.ReadFileFS = &procFS{}
+
+// A procFS is an fs.FS for the /proc/<pid> directory of a File's process.
+type procFS struct {
+	f    *File
+	fsys fs.FS
+}
+
+// Open implements fs.FS.
+func (p *procFS) Open(name string) (fs.File, error) {
+	file, err := p.fsys.Open(name)
+	if err != nil {
+		return nil, p.check(err)
+	}
+
+	if err := p.f.exists(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// ReadFile implements fs.ReadFileFS.
+func (p *procFS) ReadFile(name string) ([]byte, error) {
+	b, err := fs.ReadFile(p.fsys, name)
+	if err != nil {
+		return nil, p.check(err)
+	}
+
+	if err := p.f.exists(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// check reports the process's exit in place of err if the process no longer
+// exists, so that a missing process is not mistaken for a missing file.
+func (p *procFS) check(err error) error {
+	if xerr := p.f.exists(); xerr != nil {
+		return xerr
+	}
+
+	return err
+}
+
+// statField returns field n of /proc/<pid>/stat, numbered as in proc(5).
+// Fields 1 (pid) and 2 (comm) are not supported.
+func (f *File) statField(n int) (string, error) {
+	b, err := f.readProc("stat")
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := parseStatField(b, n)
+	if !ok {
+		return "", f.wrap(fmt.Errorf("malformed /proc/%d/stat", f.pid))
+	}
+
+	return s, nil
+}
+
+// parseStatField parses field n from the contents of a /proc/<pid>/stat file,
+// numbered as in proc(5). Fields 1 (pid) and 2 (comm) are not supported.
+func parseStatField(b []byte, n int) (string, bool) {
+	// comm may contain spaces and parentheses, so the remaining fields begin
+	// after the final closing parenthesis.
+	i := bytes.LastIndexByte(b, ')')
+	if i == -1 || n < 3 {
+		return "", false
+	}
+
+	fields := strings.Fields(string(b[i+1:]))
+	if n-3 >= len(fields) {
+		return "", false
+	}
+
+	return fields[n-3], true
+}
+
+// children returns the pids of all child processes of ppid by scanning /proc.
+// Processes which exit during the scan are skipped.
+func children(ppid int) ([]int, error) {
+	tree, err := processTree()
+	if err != nil {
+		return nil, err
+	}
+
+	return tree[ppid], nil
+}
+
+// descendants returns the pids of all descendant processes of root, in
+// breadth-first order, by scanning /proc. Processes which exit during the
+// scan are skipped.
+func descendants(root int) ([]int, error) {
+	tree, err := processTree()
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for next := tree[root]; len(next) > 0; {
+		pids = append(pids, next...)
+
+		var level []int
+		for _, pid := range next {
+			level = append(level, tree[pid]...)
+		}
+		next = level
+	}
+
+	return pids, nil
+}
+
+// processTree scans /proc and returns the pids of the child processes of
+// each process, indexed by the parent's pid. Processes which exit during the
+// scan are skipped.
+func processTree() (map[int][]int, error) {
+	des, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(map[int][]int)
+	for _, de := range des {
+		pid, err := strconv.Atoi(de.Name())
+		if err != nil {
+			// Not a process directory.
+			continue
+		}
+
+		b, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// Exited during the scan.
+				continue
+			}
+
+			return nil, err
+		}
+
+		// From proc(5): "(4) ppid %d: The PID of the parent of this
+		// process."
+		s, ok := parseStatField(b, 4)
+		if !ok {
+			continue
+		}
+
+		ppid, err := strconv.Atoi(s)
+		if err != nil {
+			continue
+		}
+
+		tree[ppid] = append(tree[ppid], pid)
+	}
+
+	return tree, nil
+}
+
+// procKeyValues reads the "key: value" lines of the file name from the
+// /proc/<pid> directory of the process referred to by File.
+func (f *File) procKeyValues(name string) (map[string]string, error) {
+	b, err := f.readProc(name)
+	if err != nil {
+		return nil, err
+	}
+
+	kvs := make(map[string]string)
+	for _, line := range strings.Split(string(b), "\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+
+		kvs[k] = strings.TrimSpace(v)
+	}
+
+	return kvs, nil
+}
+
+// parseUint parses a decimal /proc value, annotating any error.
+func (f *File) parseUint(s string) (uint64, error) {
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, f.wrap(err)
+	}
+
+	return v, nil
+}
+
+// parseInt parses a signed decimal /proc value, annotating any error.
+func (f *File) parseInt(s string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, f.wrap(err)
+	}
+
+	return v, nil
+}
+
+// readProc reads the file name from the /proc/<pid> directory of the process
+// referred to by File.
+//
+// The pid may have been reused by another process if the original process
+// exited and was reaped before the read, so the pidfd is used to check that
+// the process still exists after the read completes.
+func (f *File) readProc(name string) ([]byte, error) {
+	b, err := os.ReadFile(f.procPath(name))
+	if err != nil {
+		return nil, f.wrap(err)
+	}
+
+	if err := f.exists(); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// readlinkProc reads the symbolic link name from the /proc/<pid> directory of
+// the process referred to by File, checking that the process still exists
+// afterward as with readProc.
+func (f *File) readlinkProc(name string) (string, error) {
+	s, err := os.Readlink(f.procPath(name))
+	if err != nil {
+		return "", f.wrap(err)
+	}
+
+	if err := f.exists(); err != nil {
+		return "", err
+	}
+
+	return s, nil
+}
+
+// writeProc writes b to the file name in the /proc/<pid> directory of the
+// process referred to by File. The pidfd is used to check that the process
+// still exists before writing, which narrows but cannot eliminate the window
+// for writing to a process which reused the pid.
+func (f *File) writeProc(name string, b []byte) error {
+	if err := f.exists(); err != nil {
+		return err
+	}
+
+	return f.wrap(writeFile(f.procPath(name), b))
+}
+
+// writeFile writes b to the existing file at path. Unlike os.WriteFile, it
+// never creates or truncates the file, neither of which is permitted for
+// many files in /proc and /sys.
+func writeFile(path string, b []byte) error {
+	wf, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer wf.Close()
+
+	if _, err := wf.Write(b); err != nil {
+		return err
+	}
+
+	return wf.Close()
+}
+
+// procPath returns the path to name in the /proc/<pid> directory of the
+// process referred to by File.
+func (f *File) procPath(name string) string {
+	return fmt.Sprintf("/proc/%d/%s", f.pid, name)
+}
+
+// exists reports an error compatible with os.ErrNotExist if the process
+// referred to by File no longer exists.
+func (f *File) exists() error {
+	// Lacking permission to signal the process still means it exists.
+	err := f.signalZero()
+	if err != nil && !errors.Is(err, unix.EPERM) {
+		return err
+	}
+
+	return nil
+}
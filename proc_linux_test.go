@@ -0,0 +1,594 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestFileStartTime(t *testing.T) {
+	t.Parallel()
+
+	_, f, _ := testSleepFile(t, 1*time.Hour)
+
+	st1, err := f.StartTime()
+	if err != nil {
+		t.Fatalf("failed to get start time: %v", err)
+	}
+	if st1 == 0 {
+		t.Fatal("expected non-zero start time")
+	}
+
+	// The start time is immutable for the lifetime of the process.
+	st2, err := f.StartTime()
+	if err != nil {
+		t.Fatalf("failed to get start time again: %v", err)
+	}
+	if st1 != st2 {
+		t.Fatalf("start time changed: %d != %d", st1, st2)
+	}
+}
+
+func TestFileStartTimeNotExist(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+	testReap(t, cmd)
+
+	if _, err := f.StartTime(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileOOMScoreAdj(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	// Raising the adjustment never requires privileges.
+	const want = 500
+	if err := f.SetOOMScoreAdj(want); err != nil {
+		t.Fatalf("failed to set OOM score adjustment: %v", err)
+	}
+
+	got, err := f.OOMScoreAdj()
+	if err != nil {
+		t.Fatalf("failed to get OOM score adjustment: %v", err)
+	}
+	if got != want {
+		t.Fatalf("unexpected OOM score adjustment: want: %d, got: %d", want, got)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.OOMScoreAdj(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for get, but got: %v", err)
+	}
+	if err := f.SetOOMScoreAdj(want); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for set, but got: %v", err)
+	}
+}
+
+func TestFileNumFDs(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	// sleep has at least standard input, output, and error open.
+	n, err := f.NumFDs()
+	if err != nil {
+		t.Fatalf("failed to count file descriptors: %v", err)
+	}
+	if n < 3 {
+		t.Fatalf("expected at least 3 file descriptors, but got: %d", n)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.NumFDs(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileSocketCounts(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer l.Close()
+
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatalf("failed to create socket pair: %v", err)
+	}
+	defer unix.Close(fds[0])
+	defer unix.Close(fds[1])
+
+	self, err := pidfd.OpenSelf()
+	if err != nil {
+		t.Fatalf("failed to open self: %v", err)
+	}
+	defer self.Close()
+
+	// Other tests may open sockets concurrently.
+	counts, err := self.SocketCounts()
+	if err != nil {
+		t.Fatalf("failed to count sockets: %v", err)
+	}
+	if counts["tcp"] < 1 || counts["unix"] < 2 {
+		t.Fatalf("unexpected socket counts: %v", counts)
+	}
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+	testReap(t, cmd)
+
+	if _, err := f.SocketCounts(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileTTY(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	// The test process's children have no controlling terminal unless one is
+	// explicitly configured.
+	tty, err := f.TTY()
+	if err != nil {
+		t.Fatalf("failed to get TTY: %v", err)
+	}
+	if tty != "" && tty != testSelfTTY(t) {
+		t.Fatalf("unexpected TTY: %q", tty)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.TTY(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileTTYPseudoterminal(t *testing.T) {
+	t.Parallel()
+
+	ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("skipping, failed to open pseudoterminal: %v", err)
+	}
+	defer ptmx.Close()
+
+	if err := unix.IoctlSetPointerInt(int(ptmx.Fd()), unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatalf("failed to unlock pseudoterminal: %v", err)
+	}
+	n, err := unix.IoctlGetInt(int(ptmx.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Fatalf("failed to get pseudoterminal number: %v", err)
+	}
+
+	want := fmt.Sprintf("/dev/pts/%d", n)
+	pts, err := os.OpenFile(want, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatalf("failed to open pseudoterminal replica: %v", err)
+	}
+	defer pts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	// Start the child in a new session with the replica as its controlling
+	// terminal.
+	cmd := exec.CommandContext(ctx, "sleep", "3600")
+	cmd.Stdin = pts
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+
+	f, err := pidfd.Start(cmd)
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+	defer testReap(t, cmd)
+
+	got, err := f.TTY()
+	if err != nil {
+		t.Fatalf("failed to get TTY: %v", err)
+	}
+	if got != want {
+		t.Fatalf("unexpected TTY: want: %q, got: %q", want, got)
+	}
+}
+
+func testSelfTTY(t *testing.T) string {
+	t.Helper()
+
+	f, err := pidfd.Open(os.Getpid())
+	if err != nil {
+		t.Fatalf("failed to open self: %v", err)
+	}
+	defer f.Close()
+
+	tty, err := f.TTY()
+	if err != nil {
+		t.Fatalf("failed to get self TTY: %v", err)
+	}
+
+	return tty
+}
+
+func TestFileEnviron(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		env  []string
+	}{
+		{
+			name: "empty",
+			env:  []string{},
+		},
+		{
+			name: "variables",
+			env:  []string{"FOO=bar", "EMPTY=", "SPACES=a b c"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+			defer cancel()
+
+			path, err := exec.LookPath("sleep")
+			if err != nil {
+				t.Fatalf("failed to find sleep: %v", err)
+			}
+
+			cmd := exec.CommandContext(ctx, path, "3600")
+			cmd.Env = tt.env
+
+			f, err := pidfd.Start(cmd)
+			if err != nil {
+				t.Fatalf("failed to start command: %v", err)
+			}
+			defer f.Close()
+			defer testReap(t, cmd)
+
+			env, err := f.Environ()
+			if err != nil {
+				t.Fatalf("failed to get environment: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.env, env); diff != "" {
+				t.Fatalf("unexpected environment (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFileContextSwitches(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	// Once sleep blocks in the kernel, it has performed a voluntary context
+	// switch.
+	if err := f.WaitForState(ctx, 'S'); err != nil {
+		t.Fatalf("failed to wait for sleeping state: %v", err)
+	}
+
+	vol, _, err := f.ContextSwitches()
+	if err != nil {
+		t.Fatalf("failed to get context switches: %v", err)
+	}
+	if vol == 0 {
+		t.Fatal("expected at least one voluntary context switch")
+	}
+
+	testReap(t, cmd)
+
+	if _, _, err := f.ContextSwitches(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestOpenChildren(t *testing.T) {
+	t.Parallel()
+
+	_, _, cmd1 := testSleepFile(t, 1*time.Hour)
+	_, _, cmd2 := testSleepFile(t, 1*time.Hour)
+
+	files, err := pidfd.OpenChildren()
+	if err != nil {
+		t.Fatalf("failed to open children: %v", err)
+	}
+	defer pidfd.CloseAll(files...)
+
+	// Other tests may start children concurrently, so only check that the
+	// test's children are present.
+	pids := make(map[int]bool)
+	for _, f := range files {
+		info, err := f.Info()
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed to get info: %v", err)
+		}
+
+		if info.PPID != os.Getpid() {
+			t.Fatalf("pid %d is not a child of the test process", info.PID)
+		}
+		pids[info.PID] = true
+	}
+
+	for _, cmd := range []*exec.Cmd{cmd1, cmd2} {
+		if !pids[cmd.Process.Pid] {
+			t.Fatalf("child %d was not opened", cmd.Process.Pid)
+		}
+	}
+}
+
+func TestFileIsKernelThread(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	kthread, err := f.IsKernelThread()
+	if err != nil {
+		t.Fatalf("failed to check for kernel thread: %v", err)
+	}
+	if kthread {
+		t.Fatal("child process must not be a kernel thread")
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.IsKernelThread(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+
+	// kthreadd is only visible in the initial pid namespace.
+	if b, err := os.ReadFile("/proc/2/comm"); err != nil || string(b) != "kthreadd\n" {
+		t.Skip("skipping, kthreadd is not visible")
+	}
+
+	kf, err := pidfd.Open(2)
+	if err != nil {
+		t.Fatalf("failed to open kthreadd: %v", err)
+	}
+	defer kf.Close()
+
+	kthread, err = kf.IsKernelThread()
+	if err != nil {
+		t.Fatalf("failed to check for kernel thread: %v", err)
+	}
+	if !kthread {
+		t.Fatal("kthreadd must be a kernel thread")
+	}
+}
+
+func TestFileProcFS(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	fsys, err := f.ProcFS()
+	if err != nil {
+		t.Fatalf("failed to get proc file system: %v", err)
+	}
+
+	b, err := fs.ReadFile(fsys, "comm")
+	if err != nil {
+		t.Fatalf("failed to read comm: %v", err)
+	}
+	if diff := cmp.Diff("sleep\n", string(b)); diff != "" {
+		t.Fatalf("unexpected comm (-want +got):\n%s", diff)
+	}
+
+	// Files within subdirectories are also accessible.
+	if _, err := fs.Stat(fsys, "fd/0"); err != nil {
+		t.Fatalf("failed to stat stdin: %v", err)
+	}
+
+	// A missing file is reported as such while the process exists.
+	_, err = fs.ReadFile(fsys, "nonexistent")
+	var perr *fs.PathError
+	if !errors.As(err, &perr) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected path error for missing file, but got: %v", err)
+	}
+
+	testReap(t, cmd)
+
+	var pfe *pidfd.Error
+	if _, err := fs.ReadFile(fsys, "status"); !errors.As(err, &pfe) || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for read, but got: %v", err)
+	}
+	if _, err := f.ProcFS(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for ProcFS, but got: %v", err)
+	}
+}
+
+func TestFileSeccompMode(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	// The child inherits the test process's seccomp mode.
+	b, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		t.Fatalf("failed to read own status: %v", err)
+	}
+	if !strings.Contains(string(b), "\nSeccomp:") {
+		t.Skip("skipping, kernel does not support seccomp")
+	}
+
+	want := pidfd.SeccompModeDisabled
+	if strings.Contains(string(b), "\nSeccomp:\t2\n") {
+		want = pidfd.SeccompModeFilter
+	}
+
+	mode, err := f.SeccompMode()
+	if err != nil {
+		t.Fatalf("failed to get seccomp mode: %v", err)
+	}
+	if diff := cmp.Diff(want, mode); diff != "" {
+		t.Fatalf("unexpected seccomp mode (-want +got):\n%s", diff)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.SeccompMode(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileCwd(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	dir := t.TempDir()
+	cmd := exec.CommandContext(ctx, "sleep", "3600")
+	cmd.Dir = dir
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+
+	f, err := pidfd.Open(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to open pidfd: %v", err)
+	}
+	defer f.Close()
+
+	cwd, err := f.Cwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if diff := cmp.Diff(dir, cwd); diff != "" {
+		t.Fatalf("unexpected working directory (-want +got):\n%s", diff)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.Cwd(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileExeChanged(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	// The shell executes sleep once its input is closed.
+	cmd := exec.CommandContext(ctx, "sh", "-c", "read _; exec sleep 3600")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatalf("failed to create stdin pipe: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+
+	f, err := pidfd.Open(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to open pidfd: %v", err)
+	}
+	defer f.Close()
+
+	exe, err := f.Exe()
+	if err != nil {
+		t.Fatalf("failed to get executable: %v", err)
+	}
+
+	changed, err := f.ExeChanged(exe)
+	if err != nil {
+		t.Fatalf("failed to check executable: %v", err)
+	}
+	if changed {
+		t.Fatal("expected unchanged executable")
+	}
+
+	_ = stdin.Close()
+
+	for {
+		changed, err := f.ExeChanged(exe)
+		if err != nil {
+			t.Fatalf("failed to check executable: %v", err)
+		}
+		if changed {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatalf("timed out waiting for exec: %v", ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.ExeChanged(exe); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileTracerPID(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	pid, err := f.TracerPID()
+	if err != nil {
+		t.Fatalf("failed to get tracer pid: %v", err)
+	}
+	if pid != 0 {
+		t.Fatalf("expected untraced process, but got tracer: %d", pid)
+	}
+
+	// The tracer is the thread which attaches, so pin this goroutine to it.
+	// The thread exits with the test goroutine because it remains locked.
+	runtime.LockOSThread()
+
+	if err := unix.PtraceSeize(cmd.Process.Pid); err != nil {
+		t.Skipf("skipping, failed to trace child process: %v", err)
+	}
+
+	pid, err = f.TracerPID()
+	if err != nil {
+		t.Fatalf("failed to get tracer pid: %v", err)
+	}
+	if diff := cmp.Diff(unix.Gettid(), pid); diff != "" {
+		t.Fatalf("unexpected tracer pid (-want +got):\n%s", diff)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.TracerPID(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
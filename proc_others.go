@@ -0,0 +1,23 @@
+//go:build !linux
+
+package pidfd
+
+import "io/fs"
+
+func openChildren() ([]*File, error) { return nil, errUnimplemented }
+
+func (*File) startTime() (uint64, error)            { return 0, errUnimplemented }
+func (*File) oomScoreAdj() (int, error)             { return 0, errUnimplemented }
+func (*File) setOOMScoreAdj(_ int) error            { return errUnimplemented }
+func (*File) numFDs() (int, error)                  { return 0, errUnimplemented }
+func (*File) socketCounts() (map[string]int, error) { return nil, errUnimplemented }
+func (*File) tty() (string, error)                  { return "", errUnimplemented }
+func (*File) environ() ([]string, error)            { return nil, errUnimplemented }
+func (*File) cwd() (string, error)                  { return "", errUnimplemented }
+func (*File) exe() (string, error)                  { return "", errUnimplemented }
+func (*File) isKernelThread() (bool, error)         { return false, errUnimplemented }
+func (*File) procFS() (fs.FS, error)                { return nil, errUnimplemented }
+func (*File) seccompMode() (int, error)             { return 0, errUnimplemented }
+func (*File) tracerPID() (int, error)               { return 0, errUnimplemented }
+
+func (*File) contextSwitches() (uint64, uint64, error) { return 0, 0, errUnimplemented }
@@ -0,0 +1,90 @@
+package pidfd
+
+// Nice returns the nice value of the process referred to by File, from -20
+// (highest priority) to 19 (lowest priority).
+func (f *File) Nice() (int, error) { return f.nice() }
+
+// SetNice sets the nice value of the process referred to by File, from -20
+// (highest priority) to 19 (lowest priority). Lowering the nice value
+// requires CAP_SYS_NICE or a sufficient RLIMIT_NICE; if permission is denied,
+// an *Error compatible with errors.Is(err, os.ErrPermission) is returned.
+func (f *File) SetNice(nice int) error { return f.setNice(nice) }
+
+// I/O scheduling classes for IOPriority and SetIOPriority, as described in
+// ioprio_set(2).
+const (
+	IOPrioClassNone = 0
+	IOPrioClassRT   = 1
+	IOPrioClassBE   = 2
+	IOPrioClassIdle = 3
+)
+
+// IOPriority returns the I/O scheduling class and class data (priority level)
+// of the process referred to by File. A class of IOPrioClassNone indicates
+// that the process's I/O priority is derived from its nice value.
+func (f *File) IOPriority() (class, data int, err error) { return f.ioPriority() }
+
+// SetIOPriority sets the I/O scheduling class and class data (priority level)
+// of the process referred to by File. For IOPrioClassRT and IOPrioClassBE,
+// data ranges from 0 (highest priority) to 7 (lowest priority). Setting
+// IOPrioClassRT requires CAP_SYS_ADMIN; if permission is denied, an *Error
+// compatible with errors.Is(err, os.ErrPermission) is returned.
+func (f *File) SetIOPriority(class, data int) error { return f.setIOPriority(class, data) }
+
+// Scheduling policies for SchedPolicy and SetSchedPolicy, as described in
+// sched(7).
+const (
+	SchedOther    = 0
+	SchedFIFO     = 1
+	SchedRR       = 2
+	SchedBatch    = 3
+	SchedIdle     = 5
+	SchedDeadline = 6
+)
+
+// SchedPolicy returns the scheduling policy and static priority of the process
+// referred to by File. The priority is only meaningful for the real-time
+// policies SchedFIFO and SchedRR, and is zero otherwise.
+func (f *File) SchedPolicy() (policy, priority int, err error) { return f.schedPolicy() }
+
+// SetSchedPolicy sets the scheduling policy and static priority of the process
+// referred to by File. For SchedFIFO and SchedRR, priority ranges from 1
+// (lowest) to 99 (highest); for other policies, it must be zero. SchedDeadline
+// cannot be set using SetSchedPolicy. Setting a real-time policy requires
+// CAP_SYS_NICE or a sufficient RLIMIT_RTPRIO; if permission is denied, an
+// *Error compatible with errors.Is(err, os.ErrPermission) is returned.
+func (f *File) SetSchedPolicy(policy, priority int) error { return f.setSchedPolicy(policy, priority) }
+
+// RlimInfinity is the Rlimit value which indicates no limit on a resource.
+const RlimInfinity = ^uint64(0)
+
+// An Rlimit is a soft (Cur) and hard (Max) limit on the consumption of a
+// resource by a process, as described in getrlimit(2).
+type Rlimit struct {
+	Cur, Max uint64
+}
+
+// Rlimit returns the limits on resource, such as unix.RLIMIT_NOFILE, of the
+// process referred to by File. If permission is denied, an *Error compatible
+// with errors.Is(err, os.ErrPermission) is returned.
+func (f *File) Rlimit(resource int) (*Rlimit, error) { return f.rlimit(resource) }
+
+// SetRlimit sets the limits on resource, such as unix.RLIMIT_NOFILE, of the
+// process referred to by File. Raising a hard limit requires CAP_SYS_RESOURCE;
+// if permission is denied, an *Error compatible with
+// errors.Is(err, os.ErrPermission) is returned.
+func (f *File) SetRlimit(resource int, rlim Rlimit) error { return f.setRlimit(resource, rlim) }
+
+// CPUAffinity returns the CPUs on which the process referred to by File is
+// eligible to run, in ascending order, as reported by sched_getaffinity(2) for
+// the process's main thread. Each thread has its own affinity mask, so other
+// threads of the process may differ.
+func (f *File) CPUAffinity() ([]int, error) { return f.cpuAffinity() }
+
+// SetCPUAffinity sets the CPUs on which the process referred to by File is
+// eligible to run using sched_setaffinity(2). Only the process's main thread
+// is affected; threads which already exist keep their own affinity masks.
+// Setting another user's process's affinity requires CAP_SYS_NICE; if
+// permission is denied, an *Error compatible with
+// errors.Is(err, os.ErrPermission) is returned.
+func (f *File) SetCPUAffinity(cpus []int) error { return f.setCPUAffinity(cpus) }
@@ -0,0 +1,211 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// nice implements File.Nice.
+func (f *File) nice() (int, error) {
+	var prio int
+	err := f.getByPID("getpriority", func(pid int) error {
+		var err error
+		prio, err = unix.Getpriority(unix.PRIO_PROCESS, pid)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// From getpriority(2): "the raw getpriority() system call returns nice
+	// values in the range 40..1 (20 - nice)."
+	return 20 - prio, nil
+}
+
+// setNice implements File.SetNice.
+func (f *File) setNice(nice int) error {
+	return f.setByPID("setpriority", func(pid int) error {
+		return unix.Setpriority(unix.PRIO_PROCESS, pid, nice)
+	})
+}
+
+// From linux/ioprio.h.
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+	ioprioDataMask   = 1<<ioprioClassShift - 1
+)
+
+// ioPriority implements File.IOPriority.
+func (f *File) ioPriority() (int, int, error) {
+	var prio int
+	err := f.getByPID("ioprio_get", func(pid int) error {
+		r, _, errno := unix.Syscall(unix.SYS_IOPRIO_GET, ioprioWhoProcess, uintptr(pid), 0)
+		if errno != 0 {
+			return errno
+		}
+
+		prio = int(r)
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return prio >> ioprioClassShift, prio & ioprioDataMask, nil
+}
+
+// setIOPriority implements File.SetIOPriority.
+func (f *File) setIOPriority(class, data int) error {
+	prio := class<<ioprioClassShift | data&ioprioDataMask
+	return f.setByPID("ioprio_set", func(pid int) error {
+		_, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), uintptr(prio))
+		if errno != 0 {
+			return errno
+		}
+
+		return nil
+	})
+}
+
+// schedResetOnFork may be combined with a scheduling policy, from
+// linux/sched.h.
+const schedResetOnFork = 0x40000000
+
+// schedParam mirrors struct sched_param.
+type schedParam struct {
+	Priority int32
+}
+
+// schedPolicy implements File.SchedPolicy.
+func (f *File) schedPolicy() (int, int, error) {
+	var (
+		policy int
+		param  schedParam
+	)
+	err := f.getByPID("sched_getscheduler", func(pid int) error {
+		r, _, errno := unix.Syscall(unix.SYS_SCHED_GETSCHEDULER, uintptr(pid), 0, 0)
+		if errno != 0 {
+			return errno
+		}
+		policy = int(r) &^ schedResetOnFork
+
+		_, _, errno = unix.Syscall(unix.SYS_SCHED_GETPARAM, uintptr(pid), uintptr(unsafe.Pointer(&param)), 0)
+		if errno != 0 {
+			return errno
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return policy, int(param.Priority), nil
+}
+
+// setSchedPolicy implements File.SetSchedPolicy.
+func (f *File) setSchedPolicy(policy, priority int) error {
+	param := schedParam{Priority: int32(priority)}
+	return f.setByPID("sched_setscheduler", func(pid int) error {
+		_, _, errno := unix.Syscall(
+			unix.SYS_SCHED_SETSCHEDULER,
+			uintptr(pid),
+			uintptr(policy),
+			uintptr(unsafe.Pointer(&param)),
+		)
+		if errno != 0 {
+			return errno
+		}
+
+		return nil
+	})
+}
+
+// rlimit implements File.Rlimit.
+func (f *File) rlimit(resource int) (*Rlimit, error) {
+	var rlim unix.Rlimit
+	err := f.getByPID("prlimit", func(pid int) error {
+		return unix.Prlimit(pid, resource, nil, &rlim)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Rlimit{Cur: rlim.Cur, Max: rlim.Max}, nil
+}
+
+// setRlimit implements File.SetRlimit.
+func (f *File) setRlimit(resource int, rlim Rlimit) error {
+	return f.setByPID("prlimit", func(pid int) error {
+		return unix.Prlimit(pid, resource, &unix.Rlimit{Cur: rlim.Cur, Max: rlim.Max}, nil)
+	})
+}
+
+// cpuSetSize is the number of CPUs which fit in a unix.CPUSet.
+const cpuSetSize = int(unsafe.Sizeof(unix.CPUSet{})) * 8
+
+// cpuAffinity implements File.CPUAffinity.
+func (f *File) cpuAffinity() ([]int, error) {
+	var set unix.CPUSet
+	err := f.getByPID("sched_getaffinity", func(pid int) error {
+		return unix.SchedGetaffinity(pid, &set)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cpus := make([]int, 0, set.Count())
+	for cpu := 0; cpu < cpuSetSize; cpu++ {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+
+	return cpus, nil
+}
+
+// setCPUAffinity implements File.SetCPUAffinity.
+func (f *File) setCPUAffinity(cpus []int) error {
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		if cpu < 0 || cpu >= cpuSetSize {
+			return fmt.Errorf("pidfd: SetCPUAffinity CPU out of range: %d", cpu)
+		}
+
+		set.Set(cpu)
+	}
+
+	return f.setByPID("sched_setaffinity", func(pid int) error {
+		return unix.SchedSetaffinity(pid, &set)
+	})
+}
+
+// getByPID calls fn with the pid of the process referred to by File to
+// retrieve information using a pid-based system call named op. The pid may
+// have been reused if the process exited and was reaped before the call, so
+// the pidfd is used to check that the process still exists afterward.
+func (f *File) getByPID(op string, fn func(pid int) error) error {
+	if err := fn(f.pid); err != nil {
+		return f.wrap(os.NewSyscallError(op, err))
+	}
+
+	return f.exists()
+}
+
+// setByPID calls fn with the pid of the process referred to by File to
+// modify the process using a pid-based system call named op. The pidfd is
+// used to check that the process still exists beforehand, which narrows but
+// cannot eliminate the window for modifying a process which reused the pid.
+func (f *File) setByPID(op string, fn func(pid int) error) error {
+	if err := f.exists(); err != nil {
+		return err
+	}
+
+	return f.wrap(os.NewSyscallError(op, fn(f.pid)))
+}
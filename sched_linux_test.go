@@ -0,0 +1,201 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestFileNice(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	// Raising the nice value never requires privileges.
+	const want = 10
+	if err := f.SetNice(want); err != nil {
+		t.Fatalf("failed to set nice value: %v", err)
+	}
+
+	got, err := f.Nice()
+	if err != nil {
+		t.Fatalf("failed to get nice value: %v", err)
+	}
+	if got != want {
+		t.Fatalf("unexpected nice value: want: %d, got: %d", want, got)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.Nice(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for get, but got: %v", err)
+	}
+	if err := f.SetNice(want); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for set, but got: %v", err)
+	}
+}
+
+func TestFileIOPriority(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	// Lowering the best-effort priority never requires privileges.
+	const (
+		wantClass = pidfd.IOPrioClassBE
+		wantData  = 7
+	)
+	if err := f.SetIOPriority(wantClass, wantData); err != nil {
+		t.Fatalf("failed to set I/O priority: %v", err)
+	}
+
+	class, data, err := f.IOPriority()
+	if err != nil {
+		t.Fatalf("failed to get I/O priority: %v", err)
+	}
+	if class != wantClass || data != wantData {
+		t.Fatalf("unexpected I/O priority: want: %d/%d, got: %d/%d",
+			wantClass, wantData, class, data)
+	}
+
+	testReap(t, cmd)
+
+	if _, _, err := f.IOPriority(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for get, but got: %v", err)
+	}
+	if err := f.SetIOPriority(wantClass, wantData); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for set, but got: %v", err)
+	}
+}
+
+func TestFileSchedPolicy(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	policy, priority, err := f.SchedPolicy()
+	if err != nil {
+		t.Fatalf("failed to get scheduling policy: %v", err)
+	}
+	if policy != pidfd.SchedOther || priority != 0 {
+		t.Fatalf("unexpected default scheduling policy: %d/%d", policy, priority)
+	}
+
+	// Switching to the batch policy never requires privileges.
+	if err := f.SetSchedPolicy(pidfd.SchedBatch, 0); err != nil {
+		t.Fatalf("failed to set scheduling policy: %v", err)
+	}
+
+	policy, priority, err = f.SchedPolicy()
+	if err != nil {
+		t.Fatalf("failed to get scheduling policy: %v", err)
+	}
+	if policy != pidfd.SchedBatch || priority != 0 {
+		t.Fatalf("unexpected scheduling policy: %d/%d", policy, priority)
+	}
+
+	testReap(t, cmd)
+
+	if _, _, err := f.SchedPolicy(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for get, but got: %v", err)
+	}
+	if err := f.SetSchedPolicy(pidfd.SchedBatch, 0); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for set, but got: %v", err)
+	}
+}
+
+func TestFileRlimit(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	rlim, err := f.Rlimit(unix.RLIMIT_NOFILE)
+	if err != nil {
+		t.Fatalf("failed to get limit: %v", err)
+	}
+
+	// Lowering the soft limit never requires privileges.
+	want := pidfd.Rlimit{Cur: 64, Max: rlim.Max}
+	if err := f.SetRlimit(unix.RLIMIT_NOFILE, want); err != nil {
+		t.Fatalf("failed to set limit: %v", err)
+	}
+
+	rlim, err = f.Rlimit(unix.RLIMIT_NOFILE)
+	if err != nil {
+		t.Fatalf("failed to get limit: %v", err)
+	}
+	if diff := cmp.Diff(&want, rlim); diff != "" {
+		t.Fatalf("unexpected limit (-want +got):\n%s", diff)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.Rlimit(unix.RLIMIT_NOFILE); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for get, but got: %v", err)
+	}
+	if err := f.SetRlimit(unix.RLIMIT_NOFILE, want); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for set, but got: %v", err)
+	}
+}
+
+func TestFileCPUAffinity(t *testing.T) {
+	t.Parallel()
+
+	_, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	// The child inherits the test process's affinity.
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		t.Fatalf("failed to get own affinity: %v", err)
+	}
+
+	var want []int
+	for cpu := 0; len(want) < set.Count(); cpu++ {
+		if set.IsSet(cpu) {
+			want = append(want, cpu)
+		}
+	}
+
+	got, err := f.CPUAffinity()
+	if err != nil {
+		t.Fatalf("failed to get affinity: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected affinity (-want +got):\n%s", diff)
+	}
+
+	// Restricting the child to a single eligible CPU never requires
+	// privileges.
+	one := want[:1]
+	if err := f.SetCPUAffinity(one); err != nil {
+		t.Fatalf("failed to set affinity: %v", err)
+	}
+
+	got, err = f.CPUAffinity()
+	if err != nil {
+		t.Fatalf("failed to get affinity: %v", err)
+	}
+	if diff := cmp.Diff(one, got); diff != "" {
+		t.Fatalf("unexpected affinity after set (-want +got):\n%s", diff)
+	}
+
+	if err := f.SetCPUAffinity([]int{-1}); err == nil {
+		t.Fatal("expected an error for negative CPU, but none occurred")
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.CPUAffinity(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for get, but got: %v", err)
+	}
+	if err := f.SetCPUAffinity(one); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist for set, but got: %v", err)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package pidfd
+
+func (*File) nice() (int, error)              { return 0, errUnimplemented }
+func (*File) setNice(_ int) error             { return errUnimplemented }
+func (*File) ioPriority() (int, int, error)   { return 0, 0, errUnimplemented }
+func (*File) setIOPriority(_, _ int) error    { return errUnimplemented }
+func (*File) schedPolicy() (int, int, error)  { return 0, 0, errUnimplemented }
+func (*File) setSchedPolicy(_, _ int) error   { return errUnimplemented }
+func (*File) rlimit(_ int) (*Rlimit, error)   { return nil, errUnimplemented }
+func (*File) setRlimit(_ int, _ Rlimit) error { return errUnimplemented }
+func (*File) cpuAffinity() ([]int, error)     { return nil, errUnimplemented }
+func (*File) setCPUAffinity(_ []int) error    { return errUnimplemented }
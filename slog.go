@@ -0,0 +1,67 @@
+//go:build go1.21
+
+package pidfd
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+// logger stores the *slog.Logger set by SetLogger, or nil if none is set.
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger sets a logger which logs operations performed by all Files: opens,
+// signals, and waits. Successful operations are logged at slog.LevelDebug and
+// failed operations at slog.LevelError, with attributes for the pid, pidfd,
+// signal, and errno where applicable. Passing nil disables logging, which is
+// the default.
+func SetLogger(l *slog.Logger) { logger.Store(l) }
+
+// logEvent logs operation op ("open", "signal", or "wait") for the process pid
+// and its File f, which is nil if the File could not be opened.
+func logEvent(ctx context.Context, op string, pid int, f *File, sig os.Signal, err error) {
+	l := logger.Load()
+	if l == nil {
+		return
+	}
+
+	level := slog.LevelDebug
+	if err != nil {
+		level = slog.LevelError
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.Int("pid", pid),
+		slog.Int("fd", f.logFD()),
+	}
+	if sig != nil {
+		attrs = append(attrs, slog.String("signal", sig.String()))
+	}
+	if err != nil {
+		if name := errnoName(innermost(err)); name != "" {
+			attrs = append(attrs, slog.String("errno", name))
+		}
+		attrs = append(attrs, slog.Any("error", err))
+	}
+
+	l.LogAttrs(ctx, level, "pidfd "+op, attrs...)
+}
+
+// logFD returns the File's pidfd for logging, or -1 if f is nil or closed.
+func (f *File) logFD() int {
+	fd := -1
+	if f == nil || f.rc == nil {
+		return fd
+	}
+
+	_ = f.rc.Control(func(cfd uintptr) {
+		fd = int(cfd)
+	})
+
+	return fd
+}
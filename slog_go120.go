@@ -0,0 +1,11 @@
+//go:build !go1.21
+
+package pidfd
+
+import (
+	"context"
+	"os"
+)
+
+// logEvent is a no-op because log/slog requires Go 1.21.
+func logEvent(_ context.Context, _ string, _ int, _ *File, _ os.Signal, _ error) {}
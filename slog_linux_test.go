@@ -0,0 +1,69 @@
+//go:build linux && go1.21
+
+package pidfd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestSetLogger(t *testing.T) {
+	// Not parallel: the logger is package-wide and would observe operations
+	// from other tests.
+	var buf bytes.Buffer
+	pidfd.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	})))
+	defer pidfd.SetLogger(nil)
+
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+	if err := f.SendSignal(unix.SIGTERM); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for child process exit: %v", err)
+	}
+
+	if _, err := pidfd.Open(12345678); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+
+	type record struct {
+		Level  string
+		Msg    string
+		PID    int
+		Signal string
+		Errno  string
+	}
+
+	var got []record
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r record
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("failed to decode log record: %v", err)
+		}
+		got = append(got, r)
+	}
+
+	pid := cmd.Process.Pid
+	want := []record{
+		{Level: "DEBUG", Msg: "pidfd open", PID: pid},
+		{Level: "DEBUG", Msg: "pidfd signal", PID: pid, Signal: "terminated"},
+		{Level: "DEBUG", Msg: "pidfd wait", PID: pid},
+		{Level: "ERROR", Msg: "pidfd open", PID: 12345678, Errno: "ESRCH"},
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected log records (-want +got):\n%s", diff)
+	}
+}
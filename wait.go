@@ -0,0 +1,657 @@
+package pidfd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// si_code values for SIGCHLD, as defined by the Linux ABI.
+const (
+	cldExited    = 1
+	cldKilled    = 2
+	cldDumped    = 3
+	cldTrapped   = 4
+	cldStopped   = 5
+	cldContinued = 6
+)
+
+// WaitInfo contains information about a process state change reported by
+// waitid(2).
+type WaitInfo struct {
+	// WaitedPID is the process ID reported by the kernel for the process,
+	// translated into the caller's pid namespace. For the process referred to
+	// by File it always equals the File's pid; it identifies the process when
+	// a method such as WaitOrReapAny reports on another child.
+	WaitedPID int
+
+	// UID is the real user ID of the process.
+	UID int
+
+	// Code is the raw si_code value which describes the state change, such as
+	// CLD_EXITED or CLD_KILLED.
+	Code int
+
+	// Status is the raw si_status value: the exit status if Code is
+	// CLD_EXITED, or otherwise the signal which caused the state change.
+	Status int
+}
+
+// Exited reports whether the process exited normally.
+func (wi *WaitInfo) Exited() bool { return wi.Code == cldExited }
+
+// ExitCode returns the exit code of a process which exited normally, or -1
+// if the process was terminated by a signal or has not exited.
+func (wi *WaitInfo) ExitCode() int {
+	if !wi.Exited() {
+		return -1
+	}
+
+	return wi.Status
+}
+
+// Signaled reports whether the process was terminated by a signal.
+func (wi *WaitInfo) Signaled() bool {
+	return wi.Code == cldKilled || wi.Code == cldDumped
+}
+
+// CoreDumped reports whether the process was terminated by a signal and
+// produced a core dump.
+func (wi *WaitInfo) CoreDumped() bool { return wi.Code == cldDumped }
+
+// Stopped reports whether the process was stopped by a signal or is stopped
+// under ptrace(2).
+func (wi *WaitInfo) Stopped() bool {
+	return wi.Code == cldStopped || wi.Code == cldTrapped
+}
+
+// StopSignal returns the signal which stopped the process, such as SIGSTOP,
+// SIGTSTP, or SIGTRAP for a ptrace(2) stop. It returns nil if the process is
+// not stopped.
+func (wi *WaitInfo) StopSignal() os.Signal {
+	if !wi.Stopped() {
+		return nil
+	}
+
+	return toSignal(wi.Status)
+}
+
+// CorePattern returns the system's core dump file name pattern from
+// /proc/sys/kernel/core_pattern if the process produced a core dump, to help
+// locate the core file. It returns the empty string if the process did not
+// produce a core dump.
+//
+// See core(5) for the pattern's format. A pattern beginning with "|" means
+// the core was piped to a helper program such as systemd-coredump, and a
+// relative pattern is relative to the process's working directory. The
+// pattern is read when CorePattern is called, and may have changed since the
+// core was dumped.
+func (wi *WaitInfo) CorePattern() (string, error) {
+	if !wi.CoreDumped() {
+		return "", nil
+	}
+
+	b, err := os.ReadFile("/proc/sys/kernel/core_pattern")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(b), "\n"), nil
+}
+
+// Signal returns the signal which terminated, stopped, or continued the
+// process, or nil if the process exited normally.
+//
+// The kernel does not report which process sent the signal: the siginfo
+// returned by waitid(2) describes the child itself, so its si_pid and si_uid
+// are WaitedPID and UID. To determine who signaled a process, use an audit
+// facility such as the audit subsystem or a signal tracing eBPF program.
+func (wi *WaitInfo) Signal() os.Signal {
+	if wi.Exited() {
+		return nil
+	}
+
+	return toSignal(wi.Status)
+}
+
+// TerminationReason returns a one-line, human-readable description of the
+// state change, such as "exited cleanly", "exited with status 1", "killed by
+// SIGTERM", "killed by SIGSEGV (core dumped)", or "stopped by SIGSTOP". It is
+// intended for logs and alerts; programs should inspect the structured fields
+// and methods of WaitInfo instead.
+func (wi *WaitInfo) TerminationReason() string {
+	switch wi.Code {
+	case cldExited:
+		if wi.Status == 0 {
+			return "exited cleanly"
+		}
+
+		return fmt.Sprintf("exited with status %d", wi.Status)
+	case cldKilled:
+		return "killed by " + wi.signalName()
+	case cldDumped:
+		return "killed by " + wi.signalName() + " (core dumped)"
+	case cldTrapped:
+		return "trapped by " + wi.signalName()
+	case cldStopped:
+		return "stopped by " + wi.signalName()
+	case cldContinued:
+		return "continued by " + wi.signalName()
+	default:
+		return fmt.Sprintf("unknown state change (code %d, status %d)", wi.Code, wi.Status)
+	}
+}
+
+// signalName returns the symbolic name of the signal in Status, or its number
+// if the name is unknown.
+func (wi *WaitInfo) signalName() string {
+	if name := signalName(wi.Status); name != "" {
+		return name
+	}
+
+	return fmt.Sprintf("signal %d", wi.Status)
+}
+
+// IOCounters contains the I/O statistics of a process from /proc/<pid>/io.
+type IOCounters struct {
+	// Bytes passed to read-like and write-like system calls.
+	RChar, WChar uint64
+
+	// Bytes actually fetched from or sent to the storage layer.
+	ReadBytes, WriteBytes uint64
+}
+
+// WaitIO waits for the process referred to by File to exit, and returns its
+// exit information and final I/O counters. The counters are read while the
+// exited process is still waitable, so the caller must still reap the process,
+// such as by calling exec.Cmd.Wait.
+//
+// If the context is canceled, WaitIO will unblock and return an error.
+func (f *File) WaitIO(ctx context.Context) (*WaitInfo, *IOCounters, error) {
+	wi, ioc, err := f.waitIO(ctx)
+	return wi, ioc, f.traceError(ctx, err)
+}
+
+// Rusage contains the resource usage of an exited process, as reported by
+// waitid(2). As with getrusage(2) and RUSAGE_CHILDREN, the usage includes
+// that of the process's descendants which it waited for.
+type Rusage struct {
+	utime, stime   time.Duration
+	maxRSS         uint64
+	minflt, majflt uint64
+}
+
+// UserTime returns the CPU time spent executing in user mode.
+func (ru *Rusage) UserTime() time.Duration { return ru.utime }
+
+// SystemTime returns the CPU time spent executing in kernel mode.
+func (ru *Rusage) SystemTime() time.Duration { return ru.stime }
+
+// MaxRSS returns the peak resident set size in bytes.
+func (ru *Rusage) MaxRSS() uint64 { return ru.maxRSS }
+
+// MinorFaults returns the number of page faults which were serviced without
+// any I/O, such as by reclaiming a page from the page cache.
+func (ru *Rusage) MinorFaults() uint64 { return ru.minflt }
+
+// MajorFaults returns the number of page faults which required I/O, such as
+// reading a page from disk.
+func (ru *Rusage) MajorFaults() uint64 { return ru.majflt }
+
+// WaitRusage waits for the process referred to by File to exit, and returns
+// its exit information and resource usage. As with Wait, the process is not
+// reaped.
+//
+// If the context is canceled, WaitRusage will unblock and return an error.
+func (f *File) WaitRusage(ctx context.Context) (*WaitInfo, *Rusage, error) {
+	wi, ru, err := f.waitRusage(ctx)
+	return wi, ru, f.traceError(ctx, err)
+}
+
+// TryWait checks whether the process referred to by File has exited without
+// blocking. If the process has exited, TryWait returns its exit information;
+// otherwise, it returns nil WaitInfo and nil error. As with Wait, the process
+// is not reaped.
+//
+// TryWait performs a single nonblocking waitid(2) system call on the pidfd and
+// bypasses the Go runtime network poller, making it inexpensive enough to
+// check many Files in a loop.
+func (f *File) TryWait() (*WaitInfo, error) {
+	return f.tryWait()
+}
+
+// Peek waits for the process referred to by File to exit, and returns its exit
+// information without reaping it. It is the first phase of a two-phase wait:
+// after inspecting the WaitInfo, the caller may reap the process with Consume,
+// or leave it for another waiter to reap.
+//
+// If the context is canceled, Peek will unblock and return an error.
+func (f *File) Peek(ctx context.Context) (*WaitInfo, error) {
+	wi, err := f.waitExit(ctx)
+	return wi, f.traceError(ctx, err)
+}
+
+// Consume reaps the exited process referred to by File without blocking, and
+// returns its exit information. It is the second phase of a two-phase wait
+// started by Peek. If the process has not exited, Consume returns nil WaitInfo
+// and nil error. The process must be a child of the caller.
+//
+// A process can only be reaped once. If the process was started with os/exec,
+// exec.Cmd.Wait must not be called after Consume, and Consume returns an
+// error compatible with errors.Is(err, syscall.ECHILD) if exec.Cmd.Wait was
+// called first.
+func (f *File) Consume() (*WaitInfo, error) { return f.consume() }
+
+// WaitPreferExit waits for the process referred to by File to exit, and
+// returns its exit information. Unlike other wait methods, which report a
+// context error if the context is canceled at about the same time the process
+// exits, WaitPreferExit checks whether the process exited when the context is
+// canceled, and if so, returns its exit information rather than the context
+// error.
+//
+// If the context is canceled and the process has not exited, WaitPreferExit
+// will unblock and return an error.
+func (f *File) WaitPreferExit(ctx context.Context) (*WaitInfo, error) {
+	wi, err := f.waitExit(ctx)
+	if err != nil && ctx.Err() != nil {
+		if twi, terr := f.tryWait(); terr == nil && twi != nil {
+			return twi, nil
+		}
+	}
+
+	return wi, f.traceError(ctx, err)
+}
+
+// WaitTimed waits for the process referred to by File to exit, and returns its
+// exit information along with how long WaitTimed was blocked waiting for the
+// exit. This is useful for measuring how long a process takes to exit after
+// being signaled. The duration is also returned if an error occurs.
+//
+// If the context is canceled, WaitTimed will unblock and return an error.
+func (f *File) WaitTimed(ctx context.Context) (*WaitInfo, time.Duration, error) {
+	start := time.Now()
+	wi, err := f.waitExit(ctx)
+	return wi, time.Since(start), f.traceError(ctx, err)
+}
+
+// WaitPoll waits for the process referred to by File to exit by polling with
+// a nonblocking waitid(2) every interval, rather than waiting for the pidfd to
+// become readable as Wait does. It is a fallback for sandboxes which prohibit
+// polling pidfds but permit waitid(2).
+//
+// WaitPoll notices an exit up to interval later than Wait would, and a short
+// interval costs CPU time with a system call on every tick. interval must be
+// positive.
+//
+// If the context is canceled, WaitPoll will unblock and return an error.
+func (f *File) WaitPoll(ctx context.Context, interval time.Duration) (*WaitInfo, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("pidfd: WaitPoll interval must be positive: %s", interval)
+	}
+
+	wi, err := f.waitPoll(ctx, interval)
+	return wi, f.traceError(ctx, err)
+}
+
+// WaitCompat waits for the process referred to by File to exit and reaps it,
+// returning its exit information. It is intended for sandboxes whose seccomp
+// filters reject waitid(2) but permit wait4(2). The process must be a child
+// of the caller.
+//
+// WaitCompat first attempts to reap the process using waitid(2) with the
+// pidfd. If that fails with ENOSYS or EPERM, WaitCompat instead waits for the
+// pidfd to become readable and reaps the process by pid using wait4(2). The
+// pid-based fallback is not race-free: if the process is reaped by other
+// means, such as exec.Cmd.Wait, and its pid is reused by another child of the
+// caller, the fallback may reap the wrong process. The fallback cannot report
+// the process's user ID, so UID is -1.
+//
+// Unlike Wait, WaitCompat always reaps the process, so the caller must not
+// reap it by other means.
+//
+// If the context is canceled, WaitCompat will unblock and return an error.
+func (f *File) WaitCompat(ctx context.Context) (*WaitInfo, error) {
+	wi, err := f.waitCompat(ctx)
+	return wi, f.traceError(ctx, err)
+}
+
+// WaitRobust waits for the process referred to by File to exit, and returns
+// its exit information. It is hardened for programs which cannot guarantee a
+// quiet signal environment. EINTR encountered while waiting for
+// the pidfd to become readable is always retried transparently; if EINTR
+// nonetheless causes the wait to fail, WaitRobust retries the entire wait up
+// to maxRetries times, after a short randomized delay to avoid retrying in
+// lockstep with a signal storm. maxRetries must not be negative.
+//
+// If the context is canceled, WaitRobust will unblock and return an error.
+func (f *File) WaitRobust(ctx context.Context, maxRetries int) (*WaitInfo, error) {
+	if maxRetries < 0 {
+		return nil, fmt.Errorf("pidfd: WaitRobust retries must not be negative: %d", maxRetries)
+	}
+
+	wi, err := f.waitRobust(ctx, maxRetries)
+	return wi, f.traceError(ctx, err)
+}
+
+// WaitResilient waits for the process referred to by File to exit, and returns
+// its exit information. It is intended for long-lived supervisors which cannot
+// afford to lose track of a child if the File's pidfd is invalidated, such as
+// by shared code which closes a file descriptor it does not own. If the wait
+// fails with EBADF, WaitResilient opens a new pidfd for the File's pid once
+// and waits using it instead. The File itself is not repaired.
+//
+// Reopening by pid is not race-free: if the original process exited and was
+// reaped, its pid may have been reused by another process, which the new
+// pidfd would then refer to. A child process cannot be reaped by anyone but
+// its parent, so WaitResilient is only safe for children of the caller which
+// have not been reaped by other means.
+//
+// If the context is canceled, WaitResilient will unblock and return an error.
+func (f *File) WaitResilient(ctx context.Context) (*WaitInfo, error) {
+	wi, err := f.waitResilient(ctx)
+	return wi, f.traceError(ctx, err)
+}
+
+// WaitProcessState waits for the process referred to by File to exit, reaps
+// it, and returns its exit status as an *os.ProcessState, for interoperability
+// with code which consumes the result of exec.Cmd.Wait or os.Process.Wait. The
+// process must be a child of the caller. Unlike Wait, the process is reaped,
+// so exec.Cmd.Wait must not be called afterward.
+//
+// os.ProcessState cannot be constructed outside of package os, so once the
+// pidfd reports the exit, WaitProcessState reaps the process by pid with
+// os.Process.Wait. The exited process is not reaped before then, so its pid
+// cannot have been reused. The resulting ProcessState is produced by package
+// os itself, and all of its fields, including the resource usage reported by
+// SysUsage, are faithful.
+//
+// If the context is canceled, WaitProcessState will unblock and return an
+// error without reaping the process.
+func (f *File) WaitProcessState(ctx context.Context) (*os.ProcessState, error) {
+	ps, err := f.waitProcessState(ctx)
+	return ps, f.traceError(ctx, err)
+}
+
+// WaitWithSIGCHLD waits for the process referred to by File to exit, and
+// returns its exit information. In addition to waiting for the pidfd to
+// become readable, as Wait does, WaitWithSIGCHLD installs a transient SIGCHLD
+// notification with os/signal which also checks for the exit whenever the
+// calling process receives SIGCHLD. The notification is removed before
+// WaitWithSIGCHLD returns. As with Wait, the process is not reaped.
+//
+// WaitWithSIGCHLD is primarily a migration aid for programs moving from
+// SIGCHLD-driven reaping to pidfds, which may already deliver SIGCHLD to
+// wake other parts of the program. Wait is preferred: the pidfd alone
+// reliably reports the exit, and SIGCHLD is coalesced and shared by all
+// children of the process.
+//
+// If the context is canceled, WaitWithSIGCHLD will unblock and return an
+// error.
+func (f *File) WaitWithSIGCHLD(ctx context.Context) (*WaitInfo, error) {
+	wi, err := f.waitWithSIGCHLD(ctx)
+	return wi, f.traceError(ctx, err)
+}
+
+// WaitWithPeak waits for the process referred to by File to exit, and returns
+// its exit information along with the peak resident set size in bytes which
+// was observed while waiting.
+//
+// The peak is sampled from the VmHWM field of /proc/<pid>/status every
+// interval, and is unavailable once the process exits, so any growth after
+// the final sample is not observed; a shorter interval narrows this window at
+// the cost of more frequent reads. interval must be positive.
+//
+// If the context is canceled, WaitWithPeak will unblock and return an error.
+func (f *File) WaitWithPeak(ctx context.Context, interval time.Duration) (*WaitInfo, uint64, error) {
+	if interval <= 0 {
+		return nil, 0, fmt.Errorf("pidfd: WaitWithPeak interval must be positive: %s", interval)
+	}
+
+	wi, peak, err := f.waitWithPeak(ctx, interval)
+	return wi, peak, f.traceError(ctx, err)
+}
+
+// An Inspection is the result of WaitInspect: exit information along with
+// diagnostic information observed while waiting.
+type Inspection struct {
+	// WaitInfo is the exit information of the process.
+	WaitInfo *WaitInfo
+
+	// WChan is the name of the kernel function in which the process was most
+	// recently observed sleeping, from /proc/<pid>/wchan, such as
+	// "hrtimer_nanosleep". It is empty if the process was never observed
+	// sleeping, or if the caller lacks permission to read it.
+	WChan string
+}
+
+// WaitInspect waits for the process referred to by File to exit, and returns
+// its exit information along with diagnostic information observed while
+// waiting, such as to determine what a hung process was blocked on when it
+// was killed.
+//
+// The wait channel is sampled from /proc/<pid>/wchan every interval. A zombie
+// process has no wait channel, so the final sample necessarily precedes the
+// exit by up to interval; a shorter interval narrows this window at the cost
+// of more frequent reads. interval must be positive. As with Wait, the process
+// is not reaped.
+//
+// If the context is canceled, WaitInspect will unblock and return an error.
+func (f *File) WaitInspect(ctx context.Context, interval time.Duration) (*Inspection, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("pidfd: WaitInspect interval must be positive: %s", interval)
+	}
+
+	in, err := f.waitInspect(ctx, interval)
+	return in, f.traceError(ctx, err)
+}
+
+// WaitForState waits until the process referred to by File is in state want,
+// as reported by the state field of /proc/<pid>/stat, such as 'T' for stopped
+// or 'Z' for a zombie. See proc(5) for the possible states.
+//
+// WaitForState polls the process's state every 10ms, so it is only suitable
+// for coarse state transitions which have no dedicated wait event, and it may
+// miss states which last less than the polling interval. To wait for the
+// process to exit, use Wait instead.
+//
+// If the context is canceled, WaitForState will unblock and return an error.
+func (f *File) WaitForState(ctx context.Context, want byte) error {
+	return f.traceError(ctx, f.waitForState(ctx, want))
+}
+
+// ReapWithCheck waits for the process referred to by File to exit, calls check
+// with the process's pid while the process is still waitable, and then reaps
+// the process. It is an extension point for validating a process's final
+// state before its pid is released for reuse. The process must be a child of
+// the caller, and the caller must not reap it by other means, such as
+// exec.Cmd.Wait.
+//
+// While check runs, the exited process is a zombie: /proc/<pid> still exists
+// and files such as stat, status, and io report its final state, but its
+// file descriptors have been closed and its memory has been released, so
+// files such as fd, maps, environ, and cmdline are empty. After the process
+// is reaped, /proc/<pid> no longer exists.
+//
+// The process is reaped even if check returns an error, in which case
+// ReapWithCheck returns the WaitInfo along with check's error. If check is nil,
+// the process is reaped without a check.
+//
+// If the context is canceled, ReapWithCheck will unblock and return an error
+// without calling check or reaping the process.
+func (f *File) ReapWithCheck(ctx context.Context, check func(pid int) error) (*WaitInfo, error) {
+	wi, err := f.reapWithCheck(ctx, check)
+	return wi, f.traceError(ctx, err)
+}
+
+// WaitTree waits for the process referred to by File to exit, and then
+// returns its exit information along with the pids of the calling process's
+// other children. If the caller is a child subreaper (see PR_SET_CHILD_SUBREAPER
+// in prctl(2)), these include any descendants of the exited process which were
+// reparented to the caller, so that they may be cleaned up.
+//
+// The kernel does not record which children were reparented, so the returned
+// pids include every other child of the caller, and children may start or
+// exit at any time. The exited process itself is not reaped or included.
+//
+// If the context is canceled, WaitTree will unblock and return an error.
+func (f *File) WaitTree(ctx context.Context) (*WaitInfo, []int, error) {
+	wi, pids, err := f.waitTree(ctx)
+	return wi, pids, f.traceError(ctx, err)
+}
+
+// SendSignalWait sends sig to the process referred to by File and waits for
+// it to exit, returning its exit information. The signal is never escalated,
+// so if the process handles or ignores sig, SendSignalWait blocks until the
+// process exits for another reason or the context is canceled. As with Wait,
+// the process is not reaped.
+//
+// If the context is canceled, SendSignalWait will unblock and return an
+// error.
+func (f *File) SendSignalWait(ctx context.Context, sig os.Signal) (*WaitInfo, error) {
+	if err := f.SendSignal(sig); err != nil {
+		return nil, f.traceError(ctx, err)
+	}
+
+	wi, err := f.waitExit(ctx)
+	return wi, f.traceError(ctx, err)
+}
+
+// WaitOrElse waits for the process referred to by File to exit, and returns
+// its exit information. If the context is canceled before the process exits,
+// WaitOrElse calls onCancel with File, typically to send SIGKILL, and then
+// continues waiting for the process to exit regardless of the context. As with
+// Wait, the process is not reaped.
+//
+// onCancel must cause the process to exit, or WaitOrElse will block until it
+// exits for another reason or File is closed. If onCancel returns an error,
+// WaitOrElse returns it joined with the context's error without waiting
+// further. If onCancel is nil, no action is taken on cancelation.
+func (f *File) WaitOrElse(ctx context.Context, onCancel func(*File) error) (*WaitInfo, error) {
+	wi, err := f.waitExit(ctx)
+	if err == nil || ctx.Err() == nil || f.checkOpen() != nil {
+		return wi, f.traceError(ctx, err)
+	}
+
+	if onCancel != nil {
+		if cerr := onCancel(f); cerr != nil {
+			return nil, f.traceError(ctx, errors.Join(ctx.Err(), cerr))
+		}
+	}
+
+	wi, err = f.waitExit(context.Background())
+	return wi, f.traceError(ctx, err)
+}
+
+// KillWait sends SIGKILL to the process referred to by File and waits for it
+// to exit, returning its exit information. SIGKILL cannot be caught or
+// ignored, so KillWait normally returns promptly.
+//
+// If the context is canceled, KillWait will unblock and return an error.
+func (f *File) KillWait(ctx context.Context) (*WaitInfo, error) {
+	return f.SendSignalWait(ctx, os.Kill)
+}
+
+// CloseAndReap reaps the process referred to by File and then closes File,
+// so that a process which the caller has signaled does not remain a zombie
+// after its File is released. The process must be a child of the caller, and
+// must not be reaped by other means, such as exec.Cmd.Wait.
+//
+// If the process has not exited yet, CloseAndReap waits for it to exit until
+// the context is canceled; an already canceled context makes the reap
+// nonblocking. File is closed in either case, and any error from reaping is
+// joined with any error from Close.
+func (f *File) CloseAndReap(ctx context.Context) error {
+	err := f.consumeContext(ctx)
+	return f.traceError(ctx, errors.Join(err, f.Close()))
+}
+
+// consumeContext reaps the process referred to by File, waiting for it to exit
+// until the context is canceled.
+func (f *File) consumeContext(ctx context.Context) error {
+	wi, err := f.consume()
+	if err != nil || wi != nil {
+		return err
+	}
+
+	if _, err := f.waitExit(ctx); err != nil {
+		return err
+	}
+
+	_, err = f.consume()
+	return err
+}
+
+// SignalAndReapAsync sends sig to the process referred to by File and returns
+// immediately, leaving a goroutine to reap the process when it exits so that
+// it does not remain a zombie. It is intended for tearing down many processes
+// without blocking on any one of them. The process must be a child of the
+// caller, and must not be reaped by other means, such as exec.Cmd.Wait.
+//
+// The result of the reap is discarded. The goroutine is stopped when the File
+// is closed, so the File must remain open until the process has exited for
+// it to be reaped.
+func (f *File) SignalAndReapAsync(sig os.Signal) error {
+	if err := f.SendSignal(sig); err != nil {
+		return err
+	}
+
+	f.goBackground(func(ctx context.Context) {
+		if err := f.wait(ctx); err == nil {
+			_, _ = f.consume()
+		}
+	})
+
+	return nil
+}
+
+// KillTree sends sig to the process referred to by File and to all of its
+// descendants, and then waits for all of them to exit. It is intended for
+// shutting down a supervised process along with any processes it started.
+// As with Wait, processes are not reaped: the caller must still reap its
+// children, such as by calling exec.Cmd.Wait.
+//
+// Descendants are found by following parent pids in /proc, which is
+// inherently racy: processes started during the scan may be missed, and a
+// process which exits and is reparented before the scan is no longer found
+// in the tree. Each descendant is opened as a pidfd and checked to be a
+// member of the tree before it is signaled, so an unrelated process which
+// reuses a descendant's pid is never signaled. Making the caller a child
+// subreaper (see PR_SET_CHILD_SUBREAPER in prctl(2)) keeps orphaned
+// descendants in the caller's tree. Placing the process in its own cgroup,
+// such as with MoveToCgroup, and freezing that cgroup with Freeze prevents new
+// processes from starting during the scan; Freeze refuses to freeze a cgroup
+// which contains the caller. Frozen processes are terminated by fatal signals
+// such as SIGKILL, but do not act on other signals until they are thawed.
+//
+// If sig does not cause every process to exit, KillTree waits until the
+// context is canceled, and then returns an error.
+func (f *File) KillTree(ctx context.Context, sig os.Signal) error {
+	return f.traceError(ctx, f.killTree(ctx, sig))
+}
+
+// WaitOrReapAny waits for the process referred to by File to exit, while also
+// reaping any other exited children of the calling process to prevent them
+// from becoming zombies. It is intended for init-style processes, such as PID
+// 1 in a container, which supervise a primary child but must also reap
+// orphans reparented to them.
+//
+// WaitOrReapAny returns as soon as any child exits. If the returned WaitInfo
+// describes the process referred to by File, target is true and the process
+// is left in a waitable state, as with Wait. Otherwise, target is false and
+// the WaitInfo describes another child which has been reaped, and the caller
+// should call WaitOrReapAny again.
+//
+// Other children are checked for at least once per second while waiting.
+// WaitOrReapAny reaps any exited child of the calling process, including those
+// started and owned by exec.Cmd, whose Wait method then fails because the
+// child no longer exists. It must only be used when the caller does not wait
+// for its other children by other means.
+//
+// If the context is canceled, WaitOrReapAny will unblock and return an error.
+func (f *File) WaitOrReapAny(ctx context.Context) (info *WaitInfo, target bool, err error) {
+	info, target, err = f.waitOrReapAny(ctx)
+	return info, target, f.traceError(ctx, err)
+}
@@ -0,0 +1,630 @@
+//go:build linux
+
+package pidfd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// toSignal converts a raw signal number to an os.Signal.
+func toSignal(sig int) os.Signal { return unix.Signal(sig) }
+
+// signalName returns the symbolic name of a raw signal number.
+func signalName(sig int) string { return unix.SignalName(unix.Signal(sig)) }
+
+// waitExit waits for the process referred to by File to exit, leaving it in a
+// waitable state, and returns its exit information.
+func (f *File) waitExit(ctx context.Context) (*WaitInfo, error) {
+	return f.waitInfo(ctx, unix.WEXITED|unix.WNOWAIT)
+}
+
+// tryWait implements File.TryWait.
+func (f *File) tryWait() (*WaitInfo, error) {
+	return f.tryWaitInfo(unix.WEXITED | unix.WNOWAIT)
+}
+
+// consume implements File.Consume.
+func (f *File) consume() (*WaitInfo, error) {
+	return f.tryWaitInfo(unix.WEXITED)
+}
+
+// waitIO implements File.WaitIO.
+func (f *File) waitIO(ctx context.Context) (*WaitInfo, *IOCounters, error) {
+	// Keep the process waitable so /proc/<pid>/io remains available.
+	wi, err := f.waitExit(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	kvs, err := f.procKeyValues("io")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ioc IOCounters
+	for _, v := range []struct {
+		key string
+		dst *uint64
+	}{
+		{key: "rchar", dst: &ioc.RChar},
+		{key: "wchar", dst: &ioc.WChar},
+		{key: "read_bytes", dst: &ioc.ReadBytes},
+		{key: "write_bytes", dst: &ioc.WriteBytes},
+	} {
+		if *v.dst, err = f.parseUint(kvs[v.key]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return wi, &ioc, nil
+}
+
+// waitRusage implements File.WaitRusage.
+func (f *File) waitRusage(ctx context.Context) (*WaitInfo, *Rusage, error) {
+	// From the kernel's wait_task_zombie: resource usage is reported even with
+	// WNOWAIT, which leaves the process waitable.
+	var ru unix.Rusage
+	si, err := f.waitid(ctx, unix.WEXITED|unix.WNOWAIT, &ru)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newWaitInfo(si), &Rusage{
+		utime: time.Duration(ru.Utime.Nano()),
+		stime: time.Duration(ru.Stime.Nano()),
+		// From getrusage(2): "This is the maximum resident set size used (in
+		// kilobytes)."
+		maxRSS: uint64(ru.Maxrss) * 1024,
+		minflt: uint64(ru.Minflt),
+		majflt: uint64(ru.Majflt),
+	}, nil
+}
+
+// waitTree implements File.WaitTree.
+func (f *File) waitTree(ctx context.Context) (*WaitInfo, []int, error) {
+	wi, err := f.waitExit(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Orphans are reparented before the parent's exit is reported.
+	pids, err := children(os.Getpid())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The exited process remains a waitable child of the caller.
+	others := pids[:0]
+	for _, pid := range pids {
+		if pid != f.pid {
+			others = append(others, pid)
+		}
+	}
+
+	return wi, others, nil
+}
+
+// robustRetryDelay is the maximum delay before WaitRobust retries a wait.
+const robustRetryDelay = 10 * time.Millisecond
+
+// waitRobust implements File.WaitRobust.
+func (f *File) waitRobust(ctx context.Context, maxRetries int) (*WaitInfo, error) {
+	for i := 0; ; i++ {
+		wi, err := f.waitExit(ctx)
+		if err == nil || !errors.Is(err, unix.EINTR) || ctx.Err() != nil {
+			return wi, err
+		}
+		if i == maxRetries {
+			return nil, fmt.Errorf("pidfd: wait interrupted after %d retries: %w", maxRetries, err)
+		}
+
+		t := time.NewTimer(time.Duration(rand.Int63n(int64(robustRetryDelay))))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// waitResilient implements File.WaitResilient.
+func (f *File) waitResilient(ctx context.Context) (*WaitInfo, error) {
+	wi, err := f.waitExit(ctx)
+	if !errors.Is(err, unix.EBADF) {
+		return wi, err
+	}
+
+	rf, oerr := open(f.pid)
+	if oerr != nil {
+		return nil, errors.Join(err, oerr)
+	}
+	defer rf.Close()
+
+	return rf.waitExit(ctx)
+}
+
+// waitProcessState implements File.WaitProcessState.
+func (f *File) waitProcessState(ctx context.Context) (*os.ProcessState, error) {
+	// The process remains a zombie until it is reaped, which pins its pid.
+	if _, err := f.waitExit(ctx); err != nil {
+		return nil, err
+	}
+
+	// FindProcess always succeeds on Unix systems.
+	p, _ := os.FindProcess(f.pid)
+	ps, err := p.Wait()
+	if err != nil {
+		return nil, f.wrap(err)
+	}
+
+	return ps, nil
+}
+
+// waitWithSIGCHLD implements File.WaitWithSIGCHLD.
+func (f *File) waitWithSIGCHLD(ctx context.Context) (*WaitInfo, error) {
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, unix.SIGCHLD)
+	defer signal.Stop(sigC)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		wi  *WaitInfo
+		err error
+	}
+
+	resC := make(chan result, 1)
+	go func() {
+		wi, err := f.waitExit(ctx)
+		resC <- result{wi: wi, err: err}
+	}()
+
+	for {
+		select {
+		case res := <-resC:
+			return res.wi, res.err
+		case <-sigC:
+			// SIGCHLD may be sent for any child, and multiple signals may be
+			// coalesced, so check whether this process exited.
+			wi, err := f.tryWait()
+			if err == nil && wi == nil {
+				continue
+			}
+
+			cancel()
+			<-resC
+			return wi, err
+		}
+	}
+}
+
+// waitWithPeak implements File.WaitWithPeak.
+func (f *File) waitWithPeak(ctx context.Context, interval time.Duration) (*WaitInfo, uint64, error) {
+	var peak uint64
+	for {
+		hwm, err := f.vmHWM()
+		switch {
+		case err == nil:
+			// VmHWM never decreases, but take the maximum anyway in case
+			// it is reset by writing to /proc/<pid>/clear_refs.
+			if hwm > peak {
+				peak = hwm
+			}
+		case errors.Is(err, os.ErrNotExist):
+			// Exited, report the exit below.
+		default:
+			return nil, 0, err
+		}
+
+		tctx, cancel := context.WithTimeout(ctx, interval)
+		wi, err := f.waitExit(tctx)
+		cancel()
+
+		switch {
+		case err == nil:
+			return wi, peak, nil
+		case ctx.Err() != nil:
+			return nil, 0, ctx.Err()
+		case errors.Is(err, context.DeadlineExceeded):
+			continue
+		default:
+			return nil, 0, err
+		}
+	}
+}
+
+// vmHWM returns the peak resident set size in bytes from /proc/<pid>/status,
+// or zero if it is not reported, as for zombie processes and kernel threads.
+func (f *File) vmHWM() (uint64, error) {
+	kvs, err := f.procKeyValues("status")
+	if err != nil {
+		return 0, err
+	}
+
+	s, ok := kvs["VmHWM"]
+	if !ok {
+		return 0, nil
+	}
+
+	kb, err := f.parseUint(strings.TrimSuffix(s, " kB"))
+	if err != nil {
+		return 0, err
+	}
+
+	return kb * 1024, nil
+}
+
+// waitInspect implements File.WaitInspect.
+func (f *File) waitInspect(ctx context.Context, interval time.Duration) (*Inspection, error) {
+	var in Inspection
+	for {
+		b, err := f.readProc("wchan")
+		switch {
+		case err == nil:
+			// "0" indicates the process is not sleeping, including when it is
+			// a zombie, so keep the previous sample.
+			if s := strings.TrimSpace(string(b)); s != "" && s != "0" {
+				in.WChan = s
+			}
+		case errors.Is(err, os.ErrNotExist):
+			// Exited, report the exit below.
+		default:
+			return nil, err
+		}
+
+		tctx, cancel := context.WithTimeout(ctx, interval)
+		wi, err := f.waitExit(tctx)
+		cancel()
+
+		switch {
+		case err == nil:
+			in.WaitInfo = wi
+			return &in, nil
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		case errors.Is(err, context.DeadlineExceeded):
+			continue
+		default:
+			return nil, err
+		}
+	}
+}
+
+// stateInterval is how often WaitForState checks the process's state.
+const stateInterval = 10 * time.Millisecond
+
+// waitForState implements File.WaitForState.
+func (f *File) waitForState(ctx context.Context, want byte) error {
+	tick := time.NewTicker(stateInterval)
+	defer tick.Stop()
+
+	for {
+		// From proc(5): "(3) state %c: One of the following characters,
+		// indicating process state".
+		s, err := f.statField(3)
+		if err != nil {
+			return err
+		}
+		if len(s) == 1 && s[0] == want {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tick.C:
+		}
+	}
+}
+
+// reapWithCheck implements File.ReapWithCheck.
+func (f *File) reapWithCheck(ctx context.Context, check func(pid int) error) (*WaitInfo, error) {
+	if _, err := f.waitExit(ctx); err != nil {
+		return nil, err
+	}
+
+	var cerr error
+	if check != nil {
+		cerr = check(f.pid)
+	}
+
+	// The process has exited, so reaping it never blocks.
+	wi, err := f.tryWaitInfo(unix.WEXITED)
+	if err != nil {
+		return nil, err
+	}
+
+	return wi, cerr
+}
+
+// killTree implements File.KillTree.
+func (f *File) killTree(ctx context.Context, sig os.Signal) error {
+	pids, err := descendants(f.pid)
+	if err != nil {
+		return f.wrap(err)
+	}
+
+	// The root's pid may have been reused during the scan, in which case the
+	// descendants belong to another process.
+	if err := f.exists(); err != nil {
+		return err
+	}
+
+	files := []*File{f}
+	defer func() { _ = CloseAll(files[1:]...) }()
+
+	tree := map[int]bool{f.pid: true}
+	for _, pid := range pids {
+		tree[pid] = true
+	}
+
+	for _, pid := range pids {
+		df, err := treeMember(pid, tree)
+		if err != nil {
+			return err
+		}
+		if df != nil {
+			files = append(files, df)
+		}
+	}
+
+	// Signal the root first: if sig is fatal, such as SIGKILL, the root
+	// cannot start more processes after it is signaled. Other signals give no
+	// such guarantee.
+	for _, df := range files {
+		if err := df.SendSignal(sig); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	for _, df := range files {
+		if err := df.waitReadable(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// treeMember opens a File for pid if its parent is in tree, or returns nil
+// File if pid no longer exists or its pid was reused outside of tree.
+func treeMember(pid int, tree map[int]bool) (*File, error) {
+	f, err := open(pid)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	// The pid may have been reused after the scan, but the pidfd now refers
+	// to a stable process whose parent can be checked.
+	s, err := f.statField(4)
+	var ppid int
+	if err == nil {
+		ppid, err = f.parseInt(s)
+	}
+
+	switch {
+	case err == nil && tree[ppid]:
+		return f, nil
+	case err == nil || errors.Is(err, os.ErrNotExist):
+		// Exited, or reused by a process outside of the tree.
+		_ = f.Close()
+		return nil, nil
+	default:
+		_ = f.Close()
+		return nil, err
+	}
+}
+
+// reapInterval is how often WaitOrReapAny checks for other exited children.
+const reapInterval = 1 * time.Second
+
+// waitOrReapAny implements File.WaitOrReapAny.
+func (f *File) waitOrReapAny(ctx context.Context) (*WaitInfo, bool, error) {
+	for {
+		// Check for any waitable child without reaping it.
+		var si unix.Siginfo
+		err := unix.Waitid(unix.P_ALL, 0, &si, unix.WEXITED|unix.WNOHANG|unix.WNOWAIT, nil)
+		switch err {
+		case nil, unix.ECHILD:
+		case unix.EINTR:
+			continue
+		default:
+			return nil, false, os.NewSyscallError("waitid", err)
+		}
+
+		switch pid := sigchldFields(&si).Pid; {
+		case pid == 0:
+			// No exited children yet.
+		case int(pid) == f.pid:
+			return newWaitInfo(&si), true, nil
+		default:
+			// Reap the other child.
+			wi, err := reap(int(pid))
+			return wi, false, err
+		}
+
+		// Wait for the target to exit, waking up periodically to reap other
+		// children.
+		tctx, cancel := context.WithTimeout(ctx, reapInterval)
+		err = f.waitReadable(tctx)
+		cancel()
+
+		switch {
+		case err == nil:
+			// The target exited, even if it is not a child of the caller.
+			wi, err := f.tryWaitInfo(unix.WEXITED | unix.WNOWAIT)
+			return wi, true, err
+		case ctx.Err() != nil:
+			return nil, false, ctx.Err()
+		case errors.Is(err, context.DeadlineExceeded):
+			continue
+		default:
+			return nil, false, err
+		}
+	}
+}
+
+// reap reaps the exited child process pid.
+func reap(pid int) (*WaitInfo, error) {
+	for {
+		var si unix.Siginfo
+		err := unix.Waitid(unix.P_PID, pid, &si, unix.WEXITED|unix.WNOHANG, nil)
+		switch err {
+		case nil:
+			return newWaitInfo(&si), nil
+		case unix.EINTR:
+			continue
+		default:
+			return nil, os.NewSyscallError("waitid", err)
+		}
+	}
+}
+
+// waitCompat implements File.WaitCompat.
+func (f *File) waitCompat(ctx context.Context) (*WaitInfo, error) {
+	wi, err := f.waitInfo(ctx, unix.WEXITED)
+	if !errors.Is(err, unix.ENOSYS) && !errors.Is(err, unix.EPERM) {
+		return wi, err
+	}
+
+	// waitid(2) is blocked, but a pidfd is still readable when its process
+	// exits, after which the process can be reaped without blocking.
+	if err := f.waitReadable(ctx); err != nil {
+		return nil, err
+	}
+
+	for {
+		var ws unix.WaitStatus
+		pid, err := unix.Wait4(f.pid, &ws, unix.WNOHANG, nil)
+		switch {
+		case err == unix.EINTR:
+			continue
+		case err != nil:
+			return nil, f.wrap(os.NewSyscallError("wait4", err))
+		case pid == 0:
+			return nil, f.wrap(fmt.Errorf("pid %d exited but is not waitable", f.pid))
+		}
+
+		return newWaitStatusInfo(pid, ws), nil
+	}
+}
+
+// newWaitStatusInfo creates a WaitInfo for an exited process from a wait
+// status, which does not report the process's user ID.
+func newWaitStatusInfo(pid int, ws unix.WaitStatus) *WaitInfo {
+	wi := &WaitInfo{
+		WaitedPID: pid,
+		UID:       -1,
+	}
+
+	switch {
+	case ws.Exited():
+		wi.Code, wi.Status = cldExited, ws.ExitStatus()
+	case ws.CoreDump():
+		wi.Code, wi.Status = cldDumped, int(ws.Signal())
+	default:
+		wi.Code, wi.Status = cldKilled, int(ws.Signal())
+	}
+
+	return wi
+}
+
+// waitPoll implements File.WaitPoll.
+func (f *File) waitPoll(ctx context.Context, interval time.Duration) (*WaitInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer f.trackWait(cancel)()
+
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+
+	for {
+		// Keep the process waitable, as with Wait.
+		wi, err := f.tryWaitInfo(unix.WEXITED | unix.WNOWAIT)
+		if err != nil || wi != nil {
+			return wi, err
+		}
+
+		select {
+		case <-ctx.Done():
+			// The File may have been closed while waiting.
+			if err := f.checkOpen(); err != nil {
+				return nil, err
+			}
+
+			return nil, ctx.Err()
+		case <-tick.C:
+		}
+	}
+}
+
+// tryWaitInfo performs a nonblocking waitid(2) for a state change specified by
+// options in the process referred to by File. It returns nil WaitInfo if no
+// such state change has occurred.
+func (f *File) tryWaitInfo(options int) (*WaitInfo, error) {
+	if err := f.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	si, err := f.rawTryWaitid(options, nil)
+	if err != nil || si == nil {
+		return nil, err
+	}
+
+	return newWaitInfo(si), nil
+}
+
+// waitInfo waits for a state change specified by options in the process
+// referred to by File and returns the resulting WaitInfo.
+func (f *File) waitInfo(ctx context.Context, options int) (*WaitInfo, error) {
+	si, err := f.waitid(ctx, options, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return newWaitInfo(si), nil
+}
+
+// newWaitInfo unpacks a WaitInfo from the SIGCHLD fields of si.
+func newWaitInfo(si *unix.Siginfo) *WaitInfo {
+	sc := sigchldFields(si)
+	return &WaitInfo{
+		WaitedPID: int(sc.Pid),
+		UID:       int(sc.Uid),
+		Code:      int(si.Code),
+		Status:    int(sc.Status),
+	}
+}
+
+// sigchld mirrors the leading SIGCHLD fields in the siginfo_t union, which
+// unix.Siginfo does not expose.
+type sigchld struct {
+	Pid    int32
+	Uid    uint32
+	Status int32
+}
+
+// sigchldOffset is the offset of the siginfo_t union: it follows three int32
+// fields and is aligned to the platform's pointer size.
+const sigchldOffset = (3*4 + unsafe.Sizeof(uintptr(0)) - 1) &^ (unsafe.Sizeof(uintptr(0)) - 1)
+
+// sigchldFields returns the SIGCHLD fields of si.
+func sigchldFields(si *unix.Siginfo) sigchld {
+	return *(*sigchld)(unsafe.Add(unsafe.Pointer(si), sigchldOffset))
+}
@@ -0,0 +1,1321 @@
+//go:build linux
+
+package pidfd_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mdlayher/pidfd"
+	"golang.org/x/sys/unix"
+)
+
+func TestFileWaitIO(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+	if err := f.SendSignal(unix.SIGTERM); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	wi, ioc, err := f.WaitIO(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for child process exit: %v", err)
+	}
+
+	want := &pidfd.WaitInfo{
+		WaitedPID: cmd.Process.Pid,
+		UID:       os.Getuid(),
+		// CLD_KILLED.
+		Code:   2,
+		Status: int(unix.SIGTERM),
+	}
+
+	if diff := cmp.Diff(want, wi); diff != "" {
+		t.Fatalf("unexpected WaitInfo (-want +got):\n%s", diff)
+	}
+
+	if !wi.Signaled() || wi.Exited() || wi.ExitCode() != -1 {
+		t.Fatalf("expected process to be terminated by a signal: %+v", wi)
+	}
+	if diff := cmp.Diff(os.Signal(unix.SIGTERM), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+
+	// sleep must at least have read its own executable.
+	if ioc.RChar == 0 {
+		t.Fatalf("expected non-zero read characters: %+v", ioc)
+	}
+}
+
+func TestFileWaitPoll(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, cmd := testSleepFile(t, 1*time.Second)
+
+	wi, err := f.WaitPoll(ctx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to poll for child process exit: %v", err)
+	}
+
+	if !wi.Exited() || wi.ExitCode() != 0 || wi.WaitedPID != cmd.Process.Pid {
+		t.Fatalf("unexpected WaitInfo: %+v", wi)
+	}
+
+	// WaitPoll leaves the process waitable.
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+}
+
+func TestFileWaitPollContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := f.WaitPoll(ctx, 10*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, but got: %v", err)
+	}
+
+	if _, err := f.WaitPoll(ctx, 0); err == nil {
+		t.Fatal("expected invalid interval error, but none occurred")
+	}
+}
+
+func TestWaitInfoStopSignal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		wi   *pidfd.WaitInfo
+		sig  os.Signal
+	}{
+		{
+			name: "exited",
+			// CLD_EXITED.
+			wi: &pidfd.WaitInfo{Code: 1, Status: 0},
+		},
+		{
+			name: "killed",
+			// CLD_KILLED.
+			wi: &pidfd.WaitInfo{Code: 2, Status: int(unix.SIGTERM)},
+		},
+		{
+			name: "trapped",
+			// CLD_TRAPPED.
+			wi:  &pidfd.WaitInfo{Code: 4, Status: int(unix.SIGTRAP)},
+			sig: unix.SIGTRAP,
+		},
+		{
+			name: "stopped",
+			// CLD_STOPPED.
+			wi:  &pidfd.WaitInfo{Code: 5, Status: int(unix.SIGTSTP)},
+			sig: unix.SIGTSTP,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.sig, tt.wi.StopSignal()); diff != "" {
+				t.Fatalf("unexpected stop signal (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFileWaitTree(t *testing.T) {
+	// Not parallel: subreaper mode applies to the entire test process.
+	if err := unix.Prctl(unix.PR_SET_CHILD_SUBREAPER, 1, 0, 0, 0); err != nil {
+		t.Skipf("skipping, failed to become subreaper: %v", err)
+	}
+	defer func() { _ = unix.Prctl(unix.PR_SET_CHILD_SUBREAPER, 0, 0, 0, 0) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	// The shell starts an orphan which is reparented to the test process
+	// when the shell exits. The orphan must not hold the stdout pipe open.
+	cmd := exec.CommandContext(ctx, "sh", "-c", "sleep 3600 >/dev/null & echo $!")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	f, err := pidfd.Start(cmd)
+	if err != nil {
+		t.Fatalf("failed to start shell: %v", err)
+	}
+	defer f.Close()
+
+	wi, pids, err := f.WaitTree(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for process tree: %v", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("failed to wait for shell: %v", err)
+	}
+
+	orphan, err := strconv.Atoi(strings.TrimSpace(out.String()))
+	if err != nil {
+		t.Fatalf("failed to parse orphan pid: %v", err)
+	}
+	defer func() {
+		_ = unix.Kill(orphan, unix.SIGKILL)
+		_, _ = unix.Wait4(orphan, nil, 0, nil)
+	}()
+
+	if !wi.Exited() {
+		t.Fatalf("expected shell to exit: %+v", wi)
+	}
+
+	var found bool
+	for _, pid := range pids {
+		if pid == cmd.Process.Pid {
+			t.Fatalf("exited process %d must not be reported", pid)
+		}
+		if pid == orphan {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("orphan %d was not reported in %v", orphan, pids)
+	}
+}
+
+func TestFileKillWait(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	wi, err := f.KillWait(ctx)
+	if err != nil {
+		t.Fatalf("failed to kill and wait for child process: %v", err)
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+	if wi.WaitedPID != cmd.Process.Pid {
+		t.Fatalf("unexpected waited pid: %d", wi.WaitedPID)
+	}
+}
+
+func TestFileWaitRusage(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", "exit 0")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+
+	f, err := pidfd.Open(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to open pidfd: %v", err)
+	}
+	defer f.Close()
+
+	wi, ru, err := f.WaitRusage(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for resource usage: %v", err)
+	}
+	if !wi.Exited() || wi.ExitCode() != 0 {
+		t.Fatalf("unexpected WaitInfo: %+v", wi)
+	}
+
+	// Executing any program faults in pages.
+	if ru.MinorFaults() == 0 || ru.MaxRSS() == 0 {
+		t.Fatalf("unexpected resource usage: minflt: %d, maxrss: %d",
+			ru.MinorFaults(), ru.MaxRSS())
+	}
+
+	// The process is still waitable.
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("failed to reap child process: %v", err)
+	}
+}
+
+func TestFileWaitOrElse(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	var called bool
+	wi, err := f.WaitOrElse(tctx, func(f *pidfd.File) error {
+		called = true
+		return f.SendSignal(unix.SIGKILL)
+	})
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+	if !called {
+		t.Fatal("onCancel was not called")
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+
+	testReap(t, cmd)
+
+	// onCancel errors are returned along with the context error.
+	_, f, _ = testSleepFile(t, 1*time.Hour)
+
+	tctx, cancel = context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	errCancel := errors.New("cancel failed")
+	_, err = f.WaitOrElse(tctx, func(_ *pidfd.File) error { return errCancel })
+	if !errors.Is(err, errCancel) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected joined cancelation errors, but got: %v", err)
+	}
+
+	// A nil onCancel keeps waiting until the process exits for another
+	// reason.
+	_, f, _ = testSleepFile(t, 1*time.Hour)
+
+	tctx, cancel = context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	timer := time.AfterFunc(100*time.Millisecond, func() { _ = f.SendSignal(unix.SIGKILL) })
+	defer timer.Stop()
+
+	wi, err = f.WaitOrElse(tctx, nil)
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+}
+
+func TestFileSendSignalWait(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	wi, err := f.SendSignalWait(ctx, unix.SIGTERM)
+	if err != nil {
+		t.Fatalf("failed to signal and wait for child process: %v", err)
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGTERM), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+	if wi.WaitedPID != cmd.Process.Pid {
+		t.Fatalf("unexpected waited pid: %d", wi.WaitedPID)
+	}
+
+	testReap(t, cmd)
+
+	if _, err := f.SendSignalWait(ctx, unix.SIGTERM); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestWaitInfoTerminationReason(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		wi   *pidfd.WaitInfo
+		want string
+	}{
+		{
+			name: "exited cleanly",
+			// CLD_EXITED.
+			wi:   &pidfd.WaitInfo{Code: 1, Status: 0},
+			want: "exited cleanly",
+		},
+		{
+			name: "exited status",
+			// CLD_EXITED.
+			wi:   &pidfd.WaitInfo{Code: 1, Status: 3},
+			want: "exited with status 3",
+		},
+		{
+			name: "killed",
+			// CLD_KILLED.
+			wi:   &pidfd.WaitInfo{Code: 2, Status: int(unix.SIGTERM)},
+			want: "killed by SIGTERM",
+		},
+		{
+			name: "dumped",
+			// CLD_DUMPED.
+			wi:   &pidfd.WaitInfo{Code: 3, Status: int(unix.SIGSEGV)},
+			want: "killed by SIGSEGV (core dumped)",
+		},
+		{
+			name: "trapped",
+			// CLD_TRAPPED.
+			wi:   &pidfd.WaitInfo{Code: 4, Status: int(unix.SIGTRAP)},
+			want: "trapped by SIGTRAP",
+		},
+		{
+			name: "stopped",
+			// CLD_STOPPED.
+			wi:   &pidfd.WaitInfo{Code: 5, Status: int(unix.SIGSTOP)},
+			want: "stopped by SIGSTOP",
+		},
+		{
+			name: "continued",
+			// CLD_CONTINUED.
+			wi:   &pidfd.WaitInfo{Code: 6, Status: int(unix.SIGCONT)},
+			want: "continued by SIGCONT",
+		},
+		{
+			name: "unknown",
+			wi:   &pidfd.WaitInfo{Code: 100, Status: 1},
+			want: "unknown state change (code 100, status 1)",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, tt.wi.TerminationReason()); diff != "" {
+				t.Fatalf("unexpected termination reason (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFileTryWait(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	wi, err := f.TryWait()
+	if err != nil {
+		t.Fatalf("failed to try wait: %v", err)
+	}
+	if wi != nil {
+		t.Fatalf("expected no WaitInfo for running process, but got: %+v", wi)
+	}
+
+	if err := f.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+	if err := f.Wait(ctx); err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	wi, err = f.TryWait()
+	if err != nil {
+		t.Fatalf("failed to try wait: %v", err)
+	}
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+}
+
+func BenchmarkFileTryWait(b *testing.B) {
+	f := benchmarkExitedFile(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := f.TryWait(); err != nil {
+			b.Fatalf("failed to try wait: %v", err)
+		}
+	}
+}
+
+func BenchmarkFileWaitExited(b *testing.B) {
+	f := benchmarkExitedFile(b)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := f.Wait(ctx); err != nil {
+			b.Fatalf("failed to wait: %v", err)
+		}
+	}
+}
+
+func BenchmarkFileWaitExitedCancelable(b *testing.B) {
+	f := benchmarkExitedFile(b)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := f.Wait(ctx); err != nil {
+			b.Fatalf("failed to wait: %v", err)
+		}
+	}
+}
+
+func BenchmarkFileWaitBlocked(b *testing.B) {
+	cmd := exec.Command("sleep", "3600")
+	if err := cmd.Start(); err != nil {
+		b.Fatalf("failed to start command: %v", err)
+	}
+	b.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	// Measure the goroutines required for each blocked Wait, in addition to
+	// the goroutines which call Wait. Each waiter uses its own File because
+	// Wait uses the read deadline of the File's pidfd.
+	const waiters = 100
+
+	files := make([]*pidfd.File, 0, waiters)
+	for i := 0; i < waiters; i++ {
+		f, err := pidfd.Open(cmd.Process.Pid)
+		if err != nil {
+			b.Fatalf("failed to open pidfd: %v", err)
+		}
+		defer f.Close()
+
+		files = append(files, f)
+	}
+
+	var before, peak int
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		before = runtime.NumGoroutine()
+		errC := make(chan error, waiters)
+		for _, f := range files {
+			go func(f *pidfd.File) { errC <- f.Wait(ctx) }(f)
+		}
+
+		// Let the waiters block and then unblock them all.
+		time.Sleep(10 * time.Millisecond)
+		peak = runtime.NumGoroutine()
+		cancel()
+
+		for j := 0; j < waiters; j++ {
+			if err := <-errC; !errors.Is(err, context.Canceled) {
+				b.Fatalf("expected context canceled, but got: %v", err)
+			}
+		}
+	}
+
+	b.ReportMetric(float64(peak-before-waiters)/waiters, "goroutines/wait")
+}
+
+// benchmarkExitedFile returns a File for a child process which has exited but
+// has not been reaped.
+func benchmarkExitedFile(b *testing.B) *pidfd.File {
+	b.Helper()
+
+	cmd := exec.Command("true")
+	f, err := pidfd.Start(cmd)
+	if err != nil {
+		b.Fatalf("failed to start command: %v", err)
+	}
+	b.Cleanup(func() {
+		_ = f.Close()
+		_ = cmd.Wait()
+	})
+
+	if err := f.Wait(context.Background()); err != nil {
+		b.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	return f
+}
+
+func TestFilePeekConsume(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	// The command is never waited for by os/exec, because Consume reaps the
+	// process.
+	cmd := exec.Command("sh", "-c", "read _; exit 3")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatalf("failed to create stdin pipe: %v", err)
+	}
+
+	f, err := pidfd.Start(cmd)
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+
+	if wi, err := f.Consume(); wi != nil || err != nil {
+		t.Fatalf("expected nothing to consume, but got: %+v, %v", wi, err)
+	}
+
+	// Let the process exit.
+	_ = stdin.Close()
+
+	// Peeking repeatedly observes the same exit.
+	for i := 0; i < 2; i++ {
+		wi, err := f.Peek(ctx)
+		if err != nil {
+			t.Fatalf("failed to peek: %v", err)
+		}
+		if !wi.Exited() || wi.ExitCode() != 3 {
+			t.Fatalf("unexpected peeked WaitInfo: %+v", wi)
+		}
+	}
+
+	wi, err := f.Consume()
+	if err != nil {
+		t.Fatalf("failed to consume: %v", err)
+	}
+	if !wi.Exited() || wi.ExitCode() != 3 {
+		t.Fatalf("unexpected consumed WaitInfo: %+v", wi)
+	}
+
+	if _, err := f.Consume(); !errors.Is(err, unix.ECHILD) {
+		t.Fatalf("expected no child process, but got: %v", err)
+	}
+}
+
+func TestFileWaitPreferExit(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	// A canceled context reports an error while the process is running.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if _, err := f.WaitPreferExit(cctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, but got: %v", err)
+	}
+
+	if err := f.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+	if err := f.WaitForState(ctx, 'Z'); err != nil {
+		t.Fatalf("failed to wait for zombie state: %v", err)
+	}
+
+	// Once the process has exited, the exit takes priority.
+	wi, err := f.WaitPreferExit(cctx)
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+}
+
+func TestFileWaitTimed(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	const delay = 100 * time.Millisecond
+	timer := time.AfterFunc(delay, func() { _ = f.SendSignal(unix.SIGKILL) })
+	defer timer.Stop()
+
+	wi, d, err := f.WaitTimed(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+	if d < delay || d > 10*time.Second {
+		t.Fatalf("unexpected wait duration: %s", d)
+	}
+}
+
+func TestFileWaitCompat(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	// The command is never waited for by os/exec, because WaitCompat reaps
+	// the process.
+	cmd := exec.Command("sh", "-c", "exit 3")
+	f, err := pidfd.Start(cmd)
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+
+	wi, err := f.WaitCompat(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	if !wi.Exited() || wi.ExitCode() != 3 || wi.WaitedPID != cmd.Process.Pid {
+		t.Fatalf("unexpected WaitInfo: %+v", wi)
+	}
+
+	// The process was reaped.
+	if err := f.Wait(ctx); !errors.Is(err, unix.ECHILD) {
+		t.Fatalf("expected no child process, but got: %v", err)
+	}
+}
+
+func TestFileWaitRobust(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	if _, err := f.WaitRobust(ctx, -1); err == nil {
+		t.Fatal("expected error for negative retries, but none occurred")
+	}
+
+	// Deliver a stream of signals to the test process while waiting.
+	sigC := make(chan os.Signal, 1)
+	signal.Notify(sigC, unix.SIGURG)
+	defer signal.Stop(sigC)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = unix.Kill(os.Getpid(), unix.SIGURG)
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+
+	timer := time.AfterFunc(100*time.Millisecond, func() { _ = f.SendSignal(unix.SIGKILL) })
+	defer timer.Stop()
+
+	wi, err := f.WaitRobust(ctx, 3)
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+}
+
+func TestFileWaitWithPeak(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	if _, _, err := f.WaitWithPeak(ctx, 0); err == nil {
+		t.Fatal("expected error for zero interval, but none occurred")
+	}
+
+	timer := time.AfterFunc(150*time.Millisecond, func() { _ = f.SendSignal(unix.SIGKILL) })
+	defer timer.Stop()
+
+	wi, peak, err := f.WaitWithPeak(ctx, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+	if peak == 0 || peak%1024 != 0 {
+		t.Fatalf("unexpected peak RSS: %d", peak)
+	}
+}
+
+func TestFileWaitForState(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+
+	if err := f.SendSignal(unix.SIGSTOP); err != nil {
+		t.Fatalf("failed to stop child process: %v", err)
+	}
+	if err := f.WaitForState(ctx, 'T'); err != nil {
+		t.Fatalf("failed to wait for stopped state: %v", err)
+	}
+
+	// The process remains stopped.
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	if err := f.WaitForState(tctx, 'S'); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, but got: %v", err)
+	}
+
+	if err := f.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to kill child process: %v", err)
+	}
+	if err := f.WaitForState(ctx, 'Z'); err != nil {
+		t.Fatalf("failed to wait for zombie state: %v", err)
+	}
+
+	testReap(t, cmd)
+
+	if err := f.WaitForState(ctx, 'Z'); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileReapWithCheck(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	// The command is never waited for by os/exec, because ReapWithCheck
+	// reaps the process.
+	cmd := exec.Command("sh", "-c", "exit 3")
+	f, err := pidfd.Start(cmd)
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+
+	errCheck := errors.New("check failed")
+	wi, err := f.ReapWithCheck(ctx, func(pid int) error {
+		if pid != cmd.Process.Pid {
+			t.Errorf("unexpected pid: %d", pid)
+		}
+
+		// The zombie's final state is still available.
+		if _, err := f.StartTime(); err != nil {
+			t.Errorf("failed to get start time: %v", err)
+		}
+
+		return errCheck
+	})
+	if !errors.Is(err, errCheck) {
+		t.Fatalf("expected check error, but got: %v", err)
+	}
+
+	if !wi.Exited() || wi.ExitCode() != 3 {
+		t.Fatalf("unexpected WaitInfo: %+v", wi)
+	}
+
+	// The process was reaped despite the check error.
+	if _, err := f.StartTime(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileReapWithCheckNil(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	// The command is never waited for by os/exec, because ReapWithCheck
+	// reaps the process.
+	cmd := exec.Command("sh", "-c", "exit 3")
+	f, err := pidfd.Start(cmd)
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+
+	// A nil check skips the check and still reaps the process.
+	wi, err := f.ReapWithCheck(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to reap child process: %v", err)
+	}
+	if !wi.Exited() || wi.ExitCode() != 3 {
+		t.Fatalf("unexpected WaitInfo: %+v", wi)
+	}
+
+	if _, err := f.StartTime(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileKillTree(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	cmd, f, err := pidfd.Command(ctx, "sh", "-c", "sleep 3600 >/dev/null & sleep 3600 >/dev/null & wait")
+	if err != nil {
+		t.Fatalf("failed to start shell: %v", err)
+	}
+	defer f.Close()
+	defer func() { _ = cmd.Wait() }()
+
+	// Wait for the shell to start its children.
+	var pids []int
+	path := fmt.Sprintf("/proc/%d/task/%d/children", cmd.Process.Pid, cmd.Process.Pid)
+	for len(pids) != 2 {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read children: %v", err)
+		}
+
+		pids = pids[:0]
+		for _, s := range strings.Fields(string(b)) {
+			pid, err := strconv.Atoi(s)
+			if err != nil {
+				t.Fatalf("failed to parse child pid: %v", err)
+			}
+			pids = append(pids, pid)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	children := make([]*pidfd.File, 0, len(pids))
+	for _, pid := range pids {
+		cf, err := pidfd.Open(pid)
+		if err != nil {
+			t.Fatalf("failed to open child: %v", err)
+		}
+		defer cf.Close()
+
+		children = append(children, cf)
+	}
+
+	if err := f.KillTree(ctx, unix.SIGKILL); err != nil {
+		t.Fatalf("failed to kill process tree: %v", err)
+	}
+
+	// Every process in the tree has exited.
+	tctx, tcancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer tcancel()
+
+	if _, err := pidfd.WaitN(tctx, len(children), children...); err != nil {
+		t.Fatalf("failed to observe exits of descendants: %v", err)
+	}
+
+	wi, _, err := f.WaitTimed(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for shell: %v", err)
+	}
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+}
+
+func TestFileWaitOrReapAny(t *testing.T) {
+	// Not parallel: WaitOrReapAny reaps any child of the test process.
+	ctx, f, cmd := testSleepFile(t, 2*time.Second)
+
+	// Start an unrelated child which exits immediately and is never waited
+	// for by os/exec.
+	other := exec.Command("true")
+	if err := other.Start(); err != nil {
+		t.Fatalf("failed to start other child: %v", err)
+	}
+
+	// Children left behind by other tests may be reaped first, so keep going
+	// until the unrelated child is seen.
+	for {
+		wi, target, err := f.WaitOrReapAny(ctx)
+		if err != nil {
+			t.Fatalf("failed to reap other child: %v", err)
+		}
+		if target {
+			t.Fatalf("expected other child to be reaped before target: %+v", wi)
+		}
+		if wi.WaitedPID != other.Process.Pid {
+			continue
+		}
+
+		if !wi.Exited() {
+			t.Fatalf("expected other child to exit: %+v", wi)
+		}
+		break
+	}
+
+	for {
+		wi, target, err := f.WaitOrReapAny(ctx)
+		if err != nil {
+			t.Fatalf("failed to wait for target: %v", err)
+		}
+		if !target {
+			continue
+		}
+
+		if wi.WaitedPID != cmd.Process.Pid {
+			t.Fatalf("unexpected target waited pid: %+v", wi)
+		}
+		break
+	}
+
+	// The target is still waitable.
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+}
+
+func TestWaitInfoCorePattern(t *testing.T) {
+	t.Parallel()
+
+	// No core dump, no pattern.
+	// CLD_KILLED.
+	pattern, err := (&pidfd.WaitInfo{Code: 2, Status: int(unix.SIGKILL)}).CorePattern()
+	if err != nil {
+		t.Fatalf("failed to get core pattern: %v", err)
+	}
+	if pattern != "" {
+		t.Fatalf("expected no core pattern, but got: %q", pattern)
+	}
+
+	b, err := os.ReadFile("/proc/sys/kernel/core_pattern")
+	if err != nil {
+		t.Skipf("skipping, failed to read core pattern: %v", err)
+	}
+
+	// CLD_DUMPED.
+	pattern, err = (&pidfd.WaitInfo{Code: 3, Status: int(unix.SIGSEGV)}).CorePattern()
+	if err != nil {
+		t.Fatalf("failed to get core pattern: %v", err)
+	}
+
+	if diff := cmp.Diff(strings.TrimSuffix(string(b), "\n"), pattern); diff != "" {
+		t.Fatalf("unexpected core pattern (-want +got):\n%s", diff)
+	}
+}
+
+func TestFileWaitResilient(t *testing.T) {
+	// Not parallel: the pidfd's file descriptor number is closed out from
+	// under the File and must not be reused by other tests until it is
+	// restored.
+	ctx, f, cmd := testSleepFile(t, 1*time.Hour)
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatalf("failed to kill child process: %v", err)
+	}
+
+	fd := int(f.EpollEvent().Fd)
+	if err := unix.Close(fd); err != nil {
+		t.Fatalf("failed to close pidfd: %v", err)
+	}
+
+	wi, err := f.WaitResilient(ctx)
+
+	// Occupy the file descriptor number again so that closing the File
+	// cannot close an unrelated file. The File takes ownership of it.
+	null, nerr := unix.Open(os.DevNull, unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if nerr != nil {
+		t.Fatalf("failed to open null device: %v", nerr)
+	}
+	if null != fd {
+		if nerr := unix.Dup3(null, fd, unix.O_CLOEXEC); nerr != nil {
+			t.Fatalf("failed to restore file descriptor: %v", nerr)
+		}
+		_ = unix.Close(null)
+	}
+
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+}
+
+func TestFileSignalAndReapAsync(t *testing.T) {
+	t.Parallel()
+
+	// The command is never waited for by os/exec, because SignalAndReapAsync
+	// reaps the process.
+	cmd := exec.Command("sleep", "3600")
+	f, err := pidfd.Start(cmd)
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+
+	if err := f.SignalAndReapAsync(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	testWaitReaped(t, f)
+}
+
+func TestFileSignalAndReapAsyncCancelWait(t *testing.T) {
+	t.Parallel()
+
+	// The command is never waited for by os/exec, because SignalAndReapAsync
+	// reaps the process.
+	cmd := exec.Command("sleep", "3600")
+	f, err := pidfd.Start(cmd)
+	if err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+	defer f.Close()
+
+	// The process ignores SIGCONT, so the reap goroutine keeps waiting.
+	if err := f.SignalAndReapAsync(unix.SIGCONT); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	// Give the reap goroutine time to start waiting; CancelWait must not stop
+	// it.
+	time.Sleep(100 * time.Millisecond)
+	if err := f.CancelWait(); err != nil {
+		t.Fatalf("failed to cancel waits: %v", err)
+	}
+
+	if err := f.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	testWaitReaped(t, f)
+}
+
+// testWaitReaped waits for the process referred to by f to be reaped, after
+// which it no longer exists.
+func testWaitReaped(t *testing.T, f *pidfd.File) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		_, err := f.StartTime()
+		if errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		if err != nil {
+			t.Fatalf("failed to get start time: %v", err)
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for child process to be reaped")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestFileCloseAndReap(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	// The command is never waited for by os/exec, because CloseAndReap reaps
+	// the process.
+	cmd := exec.Command("sleep", "3600")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+
+	f, err := pidfd.Open(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to open pidfd: %v", err)
+	}
+
+	// Keep a second File to observe the reap after the first is closed.
+	check, err := pidfd.Open(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to open pidfd: %v", err)
+	}
+	defer check.Close()
+
+	if err := f.SendSignal(unix.SIGKILL); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	if err := f.CloseAndReap(ctx); err != nil {
+		t.Fatalf("failed to close and reap: %v", err)
+	}
+
+	if _, err := f.StartTime(); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("expected closed, but got: %v", err)
+	}
+	if _, err := check.StartTime(); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected not exist, but got: %v", err)
+	}
+}
+
+func TestFileCloseAndReapCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	// The process is still running, so a canceled context returns
+	// immediately, but File is still closed.
+	if err := f.CloseAndReap(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, but got: %v", err)
+	}
+	if _, err := f.StartTime(); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("expected closed, but got: %v", err)
+	}
+}
+
+func TestFileSignalAndReapAsyncClose(t *testing.T) {
+	t.Parallel()
+
+	_, f, _ := testSleepFile(t, 1*time.Hour)
+
+	// The process ignores SIGCONT, so the reap goroutine must be stopped by
+	// Close.
+	if err := f.SignalAndReapAsync(unix.SIGCONT); err != nil {
+		t.Fatalf("failed to signal child process: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- f.Close() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("failed to close: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for Close")
+	}
+}
+
+func TestFileWaitInspect(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	if _, err := f.WaitInspect(ctx, 0); err == nil {
+		t.Fatal("expected error for zero interval, but none occurred")
+	}
+
+	// Let the process go to sleep before it is observed.
+	if err := f.WaitForState(ctx, 'S'); err != nil {
+		t.Fatalf("failed to wait for sleeping state: %v", err)
+	}
+
+	timer := time.AfterFunc(150*time.Millisecond, func() { _ = f.SendSignal(unix.SIGKILL) })
+	defer timer.Stop()
+
+	in, err := f.WaitInspect(ctx, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), in.WaitInfo.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+
+	// The wait channel is only visible with sufficient privileges, and its
+	// exact name depends on the kernel.
+	if os.Getuid() == 0 && in.WChan == "" {
+		t.Fatal("expected a wait channel for a sleeping process")
+	}
+}
+
+func TestFileWaitWithSIGCHLD(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	timer := time.AfterFunc(50*time.Millisecond, func() { _ = f.SendSignal(unix.SIGKILL) })
+	defer timer.Stop()
+
+	wi, err := f.WaitWithSIGCHLD(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for child process: %v", err)
+	}
+
+	if diff := cmp.Diff(os.Signal(unix.SIGKILL), wi.Signal()); diff != "" {
+		t.Fatalf("unexpected signal (-want +got):\n%s", diff)
+	}
+
+	// The process is still waitable.
+	if wi, err := f.TryWait(); err != nil || wi == nil {
+		t.Fatalf("expected process to remain waitable: %+v, %v", wi, err)
+	}
+}
+
+func TestFileWaitWithSIGCHLDContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, f, _ := testSleepFile(t, 1*time.Hour)
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := f.WaitWithSIGCHLD(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, but got: %v", err)
+	}
+}
+
+func TestFileWaitProcessState(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
+	defer cancel()
+
+	// The command is never waited for by os/exec, because WaitProcessState
+	// reaps the process.
+	cmd := exec.Command("sh", "-c", "read _; exit 3")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatalf("failed to create stdin pipe: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start command: %v", err)
+	}
+
+	f, err := pidfd.Open(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("failed to open pidfd: %v", err)
+	}
+	defer f.Close()
+
+	// The process is not reaped if the context is canceled first.
+	tctx, tcancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer tcancel()
+
+	if _, err := f.WaitProcessState(tctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context deadline exceeded, but got: %v", err)
+	}
+
+	// Let the process exit.
+	_ = stdin.Close()
+
+	ps, err := f.WaitProcessState(ctx)
+	if err != nil {
+		t.Fatalf("failed to wait for process state: %v", err)
+	}
+
+	if diff := cmp.Diff(cmd.Process.Pid, ps.Pid()); diff != "" {
+		t.Fatalf("unexpected pid (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(3, ps.ExitCode()); diff != "" {
+		t.Fatalf("unexpected exit code (-want +got):\n%s", diff)
+	}
+	if _, ok := ps.SysUsage().(*syscall.Rusage); !ok {
+		t.Fatalf("unexpected resource usage: %T", ps.SysUsage())
+	}
+
+	// The process has been reaped.
+	if _, err := f.WaitProcessState(ctx); !errors.Is(err, unix.ECHILD) {
+		t.Fatalf("expected ECHILD, but got: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build !linux
+
+package pidfd
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+func toSignal(_ int) os.Signal { return nil }
+func signalName(_ int) string  { return "" }
+
+func (*File) waitExit(_ context.Context) (*WaitInfo, error) { return nil, errUnimplemented }
+
+func (*File) waitIO(_ context.Context) (*WaitInfo, *IOCounters, error) {
+	return nil, nil, errUnimplemented
+}
+
+func (*File) waitRusage(_ context.Context) (*WaitInfo, *Rusage, error) {
+	return nil, nil, errUnimplemented
+}
+
+func (*File) waitPoll(_ context.Context, _ time.Duration) (*WaitInfo, error) {
+	return nil, errUnimplemented
+}
+
+func (*File) tryWait() (*WaitInfo, error) { return nil, errUnimplemented }
+func (*File) consume() (*WaitInfo, error) { return nil, errUnimplemented }
+
+func (*File) waitCompat(_ context.Context) (*WaitInfo, error) { return nil, errUnimplemented }
+
+func (*File) killTree(_ context.Context, _ os.Signal) error { return errUnimplemented }
+
+func (*File) waitRobust(_ context.Context, _ int) (*WaitInfo, error) { return nil, errUnimplemented }
+func (*File) waitResilient(_ context.Context) (*WaitInfo, error)     { return nil, errUnimplemented }
+
+func (*File) waitWithSIGCHLD(_ context.Context) (*WaitInfo, error) { return nil, errUnimplemented }
+
+func (*File) waitProcessState(_ context.Context) (*os.ProcessState, error) {
+	return nil, errUnimplemented
+}
+
+func (*File) waitInspect(_ context.Context, _ time.Duration) (*Inspection, error) {
+	return nil, errUnimplemented
+}
+
+func (*File) waitWithPeak(_ context.Context, _ time.Duration) (*WaitInfo, uint64, error) {
+	return nil, 0, errUnimplemented
+}
+
+func (*File) waitForState(_ context.Context, _ byte) error { return errUnimplemented }
+
+func (*File) reapWithCheck(_ context.Context, _ func(pid int) error) (*WaitInfo, error) {
+	return nil, errUnimplemented
+}
+
+func (*File) waitTree(_ context.Context) (*WaitInfo, []int, error) {
+	return nil, nil, errUnimplemented
+}
+
+func (*File) waitOrReapAny(_ context.Context) (*WaitInfo, bool, error) {
+	return nil, false, errUnimplemented
+}